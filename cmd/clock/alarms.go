@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/alarm"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/alert"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/audio"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/clock"
+)
+
+const (
+	// ringTimeout is how long an alarm rings if nobody answers it.
+	ringTimeout = 10 * time.Minute
+	// ringRepeat is the pause before the alarm's sound is played again.
+	ringRepeat = time.Second
+	// defaultAlarmSound is played by alarms without a sound of their own.
+	defaultAlarmSound = "beep.wav"
+)
+
+// alarmsView is the Alarms mode: the list of alarms, the form for adding
+// and editing them, and the screen shown while one rings.
+type alarmsView struct {
+	list     *alarm.List
+	disp     *display // of the time shown while an alarm rings
+	selected int
+	form     *alarmForm   // nil unless adding or editing
+	clock    chrono.Clock // for the time a new alarm starts at
+
+	ringing   *alarm.Alarm // nil unless an alarm is ringing
+	ringStart time.Time
+	lastPlay  time.Time
+
+	err error // from the last save
+}
+
+// save writes the alarms, keeping any error for display.
+func (v *alarmsView) save() {
+	v.err = v.list.Save()
+}
+
+// check starts ringing an alarm that is due. It returns the alarm if one
+// started.
+func (v *alarmsView) check(now time.Time) *alarm.Alarm {
+	if v.ringing != nil {
+		if now.Sub(v.ringStart) >= ringTimeout {
+			v.ringing = nil
+		}
+		return nil
+	}
+	a, ok := v.list.Due(now)
+	if !ok {
+		return nil
+	}
+	a.Ring(now)
+	v.save()
+	v.ringing, v.ringStart, v.lastPlay = a, now, time.Time{}
+	return a
+}
+
+// sound returns where to find a ringing alarm's sound and its name.
+func (v *alarmsView) sound(sounds fs.FS) (fs.FS, string) {
+	if v.ringing.Sound == "" {
+		return audio.ChimeFS{}, defaultAlarmSound
+	}
+	return sounds, v.ringing.Sound
+}
+
+// play keeps a ringing alarm's sound going, waiting for anything else that
+// is playing, such as the azan, to finish.
+func (v *alarmsView) play(now time.Time, player audio.Player, sounds fs.FS) {
+	if v.ringing == nil || player.State() != audio.Idle || now.Sub(v.lastPlay) < ringRepeat {
+		return
+	}
+	v.lastPlay = now
+	fsys, name := v.sound(sounds)
+	player.Play(context.Background(), fsys, name)
+}
+
+// answer snoozes or dismisses the ringing alarm. Other keys are ignored, so
+// that a stray key press cannot silence it.
+func (v *alarmsView) answer(key byte, now time.Time, player audio.Player, sounds fs.FS) {
+	switch key {
+	case 'z', 'Z':
+		v.ringing.Snooze(now)
+	case 'd', '\r', '\n':
+	default:
+		return
+	}
+	if _, name := v.sound(sounds); player.Progress().Sound == name {
+		player.Stop()
+	}
+	v.ringing = nil
+	v.save()
+}
+
+// key handles a key press in Alarms mode, reporting whether it was used.
+func (v *alarmsView) key(key byte) bool {
+	if v.form != nil {
+		switch v.form.key(key) {
+		case formCancel:
+			v.form = nil
+		case formDone:
+			a, err := v.form.alarm()
+			if err != nil {
+				v.form.err = err
+				return true
+			}
+			if v.form.editing != nil {
+				*v.form.editing = a
+			} else {
+				v.list.Add(a)
+				v.selected = len(v.list.Alarms) - 1
+			}
+			v.form = nil
+			v.save()
+		}
+		return true
+	}
+
+	switch key {
+	case keyUp:
+		v.selected = max(0, v.selected-1)
+	case keyDown:
+		v.selected = min(len(v.list.Alarms)-1, v.selected+1)
+	case 'n', 'N':
+		v.form = newAlarmForm(nil, v.clock.Now())
+	case 'e', 'E', '\r', '\n':
+		if a := v.current(); a != nil {
+			v.form = newAlarmForm(a, v.clock.Now())
+		}
+	case 'x', 'X':
+		if a := v.current(); a != nil {
+			v.list.Delete(a.ID)
+			v.selected = max(0, min(v.selected, len(v.list.Alarms)-1))
+			v.save()
+		}
+	case ' ':
+		if a := v.current(); a != nil {
+			a.Enabled = !a.Enabled
+			a.SnoozedUntil = time.Time{}
+			v.save()
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// current returns the selected alarm, if there are any.
+func (v *alarmsView) current() *alarm.Alarm {
+	if v.selected < 0 || v.selected >= len(v.list.Alarms) {
+		return nil
+	}
+	return v.list.Alarms[v.selected]
+}
+
+// render draws the list of alarms, or the form.
+func (v *alarmsView) render(now time.Time) string {
+	var b strings.Builder
+	if v.err != nil {
+		fmt.Fprintf(&b, "  \033[1;31m⚠ %v\033[0m\033[K\n\n", v.err)
+	}
+	if v.form != nil {
+		b.WriteString(v.form.render())
+		return b.String()
+	}
+	if len(v.list.Alarms) == 0 {
+		b.WriteString("  \033[90mNo alarms. Press n to add one.\033[0m\033[K\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "  \033[1m  %-5s  %-20s  %-16s  %-12s  %s\033[0m\033[K\n", "TIME", "LABEL", "REPEAT", "SOUND", "NEXT")
+	for i, a := range v.list.Alarms {
+		cursor, style := " ", ""
+		if i == v.selected {
+			cursor, style = "▶", "\033[7m"
+		}
+		sound := a.Sound
+		if sound == "" {
+			sound = "beep"
+		}
+		next := "\033[90moff\033[0m"
+		if t := a.Next(now); !t.IsZero() {
+			next = formatNext(t, now)
+			if !a.SnoozedUntil.IsZero() {
+				next = "snoozed until " + t.Format("15:04")
+			}
+		}
+		fmt.Fprintf(&b, "  %s%s%-5s  %-20s  %-16s  %-12s\033[0m  %s\033[K\n",
+			cursor, style, a.Time, truncate(a.Label, 20), a.Days, truncate(sound, 12), next)
+	}
+	return b.String()
+}
+
+// formatNext describes when an alarm next rings relative to now.
+func formatNext(t, now time.Time) string {
+	in := t.Sub(now).Round(time.Minute)
+	when := fmt.Sprintf("in %dh%02dm", int(in.Hours()), int(in.Minutes())%60)
+	if in >= 24*time.Hour {
+		when = t.Format("Mon 15:04")
+	}
+	return when
+}
+
+// truncate cuts s to at most n runes.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// renderRinging draws the full-screen alarm, with the time as large as
+// fits between its label and the keys to answer it.
+func (v *alarmsView) renderRinging(now time.Time, on bool) string {
+	width, height := v.disp.screen()
+	digits, ok := v.disp.fit(func(f *clock.Font) string {
+		return f.RenderTime(now, true, v.disp.format)
+	}, width, height-4)
+	if !ok {
+		digits = clock.TimeText(now, v.disp.format)
+	}
+	lines := []string{strings.ToUpper(v.ringing.Label), ""}
+	lines = append(lines, strings.Split(digits, "\n")...)
+	lines = append(lines, "", fmt.Sprintf("z: snooze %d min   ENTER/d: dismiss", int(v.ringing.SnoozeFor()/time.Minute)))
+	return alert.Screen(lines, width, height, on)
+}
+
+// Form results.
+const (
+	formEditing = iota
+	formDone
+	formCancel
+)
+
+// Fields of the alarm form.
+const (
+	fieldLabel = iota
+	fieldTime
+	fieldDays
+	fieldSound
+	fieldSnooze
+	fieldCount
+)
+
+var fieldNames = [fieldCount]string{"Label", "Time (HH:MM)", "Repeat", "Sound", "Snooze (min)"}
+
+var fieldHints = [fieldCount]string{
+	"",
+	"",
+	"once, daily, weekdays, weekends or days like mon,wed,fri",
+	"a file in the sounds directory, or empty for a beep",
+	"",
+}
+
+// alarmForm edits the fields of an alarm as text.
+type alarmForm struct {
+	editing *alarm.Alarm // nil when adding
+	fields  [fieldCount]string
+	focus   int
+	err     error
+}
+
+// newAlarmForm starts editing a, or a new alarm at now if a is nil.
+func newAlarmForm(a *alarm.Alarm, now time.Time) *alarmForm {
+	f := &alarmForm{editing: a}
+	if a == nil {
+		f.fields = [fieldCount]string{"Alarm", now.Format("15:04"), "once", "", strconv.Itoa(int(alarm.DefaultSnooze / time.Minute))}
+		return f
+	}
+	f.fields = [fieldCount]string{a.Label, a.Time, a.Days.String(), a.Sound, strconv.Itoa(int(a.SnoozeFor() / time.Minute))}
+	return f
+}
+
+// key edits the focused field. TAB and ENTER move to the next field, ENTER
+// on the last saves and ESC cancels.
+func (f *alarmForm) key(key byte) int {
+	switch {
+	case key == 0x1b:
+		return formCancel
+	case key == '\t':
+		f.focus = (f.focus + 1) % fieldCount
+	case key == '\r' || key == '\n':
+		if f.focus == fieldCount-1 {
+			return formDone
+		}
+		f.focus++
+	case key == 0x7f || key == 0x08: // backspace
+		if r := []rune(f.fields[f.focus]); len(r) > 0 {
+			f.fields[f.focus] = string(r[:len(r)-1])
+		}
+	case key >= 0x20 && key < 0x7f:
+		f.fields[f.focus] += string(rune(key))
+	}
+	f.err = nil
+	return formEditing
+}
+
+// alarm returns the alarm described by the form.
+func (f *alarmForm) alarm() (alarm.Alarm, error) {
+	var a alarm.Alarm
+	if f.editing != nil {
+		a = *f.editing
+	}
+	a.Label = strings.TrimSpace(f.fields[fieldLabel])
+	h, m, err := alarm.ParseTime(f.fields[fieldTime])
+	if err != nil {
+		return a, err
+	}
+	a.Time = fmt.Sprintf("%02d:%02d", h, m)
+	if a.Days, err = alarm.ParseWeekdays(f.fields[fieldDays]); err != nil {
+		return a, err
+	}
+	a.Sound = strings.TrimSpace(f.fields[fieldSound])
+	if s := strings.TrimSpace(f.fields[fieldSnooze]); s != "" {
+		if a.SnoozeMinutes, err = strconv.Atoi(s); err != nil || a.SnoozeMinutes <= 0 {
+			return a, fmt.Errorf("invalid snooze %q", s)
+		}
+	}
+	a.Enabled = true
+	a.SnoozedUntil = time.Time{}
+	return a, nil
+}
+
+// render draws the form.
+func (f *alarmForm) render() string {
+	var b strings.Builder
+	title := "New alarm"
+	if f.editing != nil {
+		title = "Edit alarm"
+	}
+	fmt.Fprintf(&b, "  \033[1m%s\033[0m   TAB/ENTER: next field  |  ESC: cancel\033[K\n\n", title)
+	for i, name := range fieldNames {
+		value := f.fields[i]
+		if i == f.focus {
+			value = "\033[7m" + value + " \033[0m"
+		}
+		fmt.Fprintf(&b, "  %-13s %s\033[K\n", name+":", value)
+		if i == f.focus && fieldHints[i] != "" {
+			fmt.Fprintf(&b, "  %-13s \033[90m%s\033[0m\033[K\n", "", fieldHints[i])
+		}
+	}
+	if f.focus == fieldCount-1 {
+		b.WriteString("\n  \033[90mENTER saves the alarm\033[0m\033[K\n")
+	}
+	if f.err != nil {
+		fmt.Fprintf(&b, "\n  \033[1;31m⚠ %v\033[0m\033[K\n", f.err)
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package main
+
+import "time"
+
+// bannerDuration is how long an alert banner flashes unless dismissed.
+const bannerDuration = time.Minute
+
+// banner is a visual alert shown over the current mode.
+type banner struct {
+	title   string
+	message string
+	until   time.Time
+	shown   bool // still on screen, to be cleared once it ends
+}
+
+// show displays the banner from now for bannerDuration.
+func (b *banner) show(title, message string, now time.Time) {
+	b.title, b.message = title, message
+	b.until = now.Add(bannerDuration)
+	b.shown = true
+}
+
+// active reports whether the banner is showing at now.
+func (b *banner) active(now time.Time) bool {
+	return now.Before(b.until)
+}
+
+// dismiss hides the banner early.
+func (b *banner) dismiss() {
+	b.until = time.Time{}
+}
+
+// expired reports, once, that the banner has stopped showing and its screen
+// needs clearing.
+func (b *banner) expired(now time.Time) bool {
+	if !b.shown || b.active(now) {
+		return false
+	}
+	b.shown = false
+	return true
+}
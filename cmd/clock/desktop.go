@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/dbus"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/notify"
+)
+
+// desktop sends desktop notifications in the background, so that a slow
+// notification server never holds up the clock. Without a session bus it
+// does nothing.
+type desktop struct {
+	notifier *notify.Notifier
+	errs     chan error
+}
+
+// newDesktop connects to the session bus if enabled. It returns a desktop
+// that does nothing if that fails, as on systems without D-Bus.
+func newDesktop(enabled bool) *desktop {
+	d := &desktop{errs: make(chan error, 1)}
+	if !enabled {
+		return d
+	}
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return d
+	}
+	if d.notifier, err = notify.New(conn, "clock"); err != nil {
+		conn.Close()
+	}
+	return d
+}
+
+// notify sends a notification. Failures are reported on Errors.
+func (d *desktop) notify(title, body string, urgency notify.Urgency, actions ...notify.Action) {
+	if d.notifier == nil {
+		return
+	}
+	go func() {
+		if _, err := d.notifier.Notify(0, title, body, urgency, actions...); err != nil {
+			select {
+			case d.errs <- err:
+			default:
+			}
+		}
+	}()
+}
+
+// Errors returns failures to send notifications.
+func (d *desktop) Errors() <-chan error {
+	return d.errs
+}
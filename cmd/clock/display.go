@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/clock"
+)
+
+// display is how the big digits are drawn, and the terminal they are
+// drawn in.
+type display struct {
+	font          *clock.Font
+	format        clock.Format // of the time of day
+	width, height int          // of the terminal; zero when unknown
+}
+
+// fallbackFonts are tried in turn when the chosen font does not fit the
+// terminal.
+var fallbackFonts = []*clock.Font{clock.Compact, clock.Braille}
+
+// resize reads the terminal's size again.
+func (d *display) resize() {
+	d.width, d.height = termSize()
+}
+
+// digits draws big digits with draw, centered and as large as fits in the
+// terminal's width and the given rows. If nothing fits, plain is shown
+// instead. When the terminal's size is unknown, the digits are drawn in the
+// chosen font as they are.
+func (d *display) digits(draw func(*clock.Font) string, plain string, rows int) string {
+	if d.width <= 0 || d.height <= 0 {
+		return draw(d.font)
+	}
+	drawing, ok := d.fit(draw, d.width, rows)
+	if !ok {
+		return d.center(plain, len(plain), "\033[1m", "\033[0m")
+	}
+	width, _ := clock.Size(drawing)
+	return d.center(drawing, width, "", "")
+}
+
+// fit draws big digits with draw as large as fits in width columns and
+// rows rows: in the chosen font, scaled up if it is made of blocks, or else
+// in a smaller font. It reports whether any of them fit.
+func (d *display) fit(draw func(*clock.Font) string, width, rows int) (string, bool) {
+	drawings := []string{draw(d.font)}
+	for _, f := range fallbackFonts {
+		if f != d.font {
+			drawings = append(drawings, draw(f))
+		}
+	}
+	return clock.Fit(drawings, width, rows)
+}
+
+// Size of the screen assumed when the terminal's is unknown.
+const (
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// screen returns the size of the terminal, or the usual 80 by 24 when it
+// is unknown.
+func (d *display) screen() (width, height int) {
+	if d.width <= 0 || d.height <= 0 {
+		return defaultWidth, defaultHeight
+	}
+	return d.width, d.height
+}
+
+// defaultAnalogSize is how many dots across the analog clock is when the
+// terminal's size is unknown.
+const defaultAnalogSize = 64
+
+// analog draws an analog clock showing now, centered and as large as fits
+// in the terminal's width and the given rows. If it would be too small to
+// read, the time is shown as plain text instead.
+func (d *display) analog(now time.Time, rows int) string {
+	if d.width <= 0 || d.height <= 0 {
+		return clock.Analog(now, defaultAnalogSize, d.format.Seconds)
+	}
+	size := min(d.width*2, rows*4)
+	if size < clock.MinAnalogSize {
+		plain := clock.TimeText(now, d.format)
+		return d.center(plain, len(plain), "\033[1m", "\033[0m")
+	}
+	return d.center(clock.Analog(now, size, d.format.Seconds), (size+1)/2, "", "")
+}
+
+// center indents each line of s, which is width columns wide, to the
+// middle of the terminal, wrapping it in before and after.
+func (d *display) center(s string, width int, before, after string) string {
+	indent := strings.Repeat(" ", max(0, (d.width-width)/2))
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = fmt.Sprintf("%s%s%s%s\033[K", indent, before, l, after)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// middle pads s with blank lines above to put it halfway down rows.
+func middle(s string, rows int) string {
+	return strings.Repeat("\n", max(0, (rows-lineCount(s))/2)) + s
+}
+
+// lineCount returns how many lines s takes on the screen.
+func lineCount(s string) int {
+	return strings.Count(s, "\n") + 1
+}
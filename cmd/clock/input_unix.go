@@ -27,17 +27,13 @@ func readKeys(ch chan<- byte) {
 	defer syscall.Syscall6(syscall.SYS_IOCTL, uintptr(fd),
 		uintptr(getTermiosSet()), uintptr(unsafe.Pointer(&oldState)), 0, 0, 0)
 
-	buf := make([]byte, 3)
+	buf := make([]byte, 64)
 	for {
 		n, err := os.Stdin.Read(buf)
 		if err != nil || n == 0 {
 			continue
 		}
-		if n == 3 && buf[0] == 0x1b && buf[1] == '[' {
-			ch <- buf[2] // 'D' for left, 'C' for right
-		} else {
-			ch <- buf[0]
-		}
+		sendKeys(ch, buf[:n])
 	}
 }
 
@@ -65,9 +65,13 @@ func readKeys(ch chan<- byte) {
 
 		switch rec.vkCode {
 		case 0x25: // VK_LEFT
-			ch <- 'D'
+			ch <- keyLeft
 		case 0x27: // VK_RIGHT
-			ch <- 'C'
+			ch <- keyRight
+		case 0x26: // VK_UP
+			ch <- keyUp
+		case 0x28: // VK_DOWN
+			ch <- keyDown
 		case 0x20: // VK_SPACE
 			ch <- ' '
 		default:
@@ -79,16 +83,12 @@ func readKeys(ch chan<- byte) {
 }
 
 func readKeysStdin(ch chan<- byte) {
-	buf := make([]byte, 3)
+	buf := make([]byte, 64)
 	for {
 		n, err := os.Stdin.Read(buf)
 		if err != nil || n == 0 {
 			continue
 		}
-		if n == 3 && buf[0] == 0x1b && buf[1] == '[' {
-			ch <- buf[2] // 'D' for left, 'C' for right
-		} else {
-			ch <- buf[0]
-		}
+		sendKeys(ch, buf[:n])
 	}
 }
@@ -0,0 +1,32 @@
+package main
+
+// Arrow keys are sent as codes above ASCII, so that they cannot be mistaken
+// for letters.
+const (
+	keyUp byte = 0x80 + iota
+	keyDown
+	keyRight
+	keyLeft
+)
+
+// arrows maps the final letter of an arrow key's escape sequence to its
+// code.
+var arrows = map[byte]byte{'A': keyUp, 'B': keyDown, 'C': keyRight, 'D': keyLeft}
+
+// sendKeys sends the keys in a chunk read from the terminal, which may hold
+// several when typing fast. Arrow keys arrive as escape sequences and are
+// sent as their codes; other sequences, and bytes beyond ASCII, which no
+// mode takes, are dropped.
+func sendKeys(ch chan<- byte, buf []byte) {
+	for i := 0; i < len(buf); i++ {
+		switch {
+		case buf[i] == 0x1b && i+2 < len(buf) && buf[i+1] == '[':
+			if key, ok := arrows[buf[i+2]]; ok {
+				ch <- key
+			}
+			i += 2
+		case buf[i] < 0x80:
+			ch <- buf[i]
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/audio"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/broadcast"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/config"
+)
+
+// startBroadcast serves sounds to listening clocks at addr, and announces
+// over the multicast group if one is given. The error that stops serving is
+// sent on the returned channel.
+func startBroadcast(addr, group string, sounds fs.FS) (*broadcast.Server, <-chan error, error) {
+	cast := broadcast.NewServer(sounds)
+	if group != "" {
+		if err := cast.Multicast(group); err != nil {
+			return nil, nil, err
+		}
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		cast.Close()
+		return nil, nil, fmt.Errorf("listen on %s: %w", addr, err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- fmt.Errorf("serve broadcast: %w", http.Serve(ln, cast)) }()
+	return cast, served, nil
+}
+
+// listen plays what the clock at url broadcasts until interrupted, showing
+// the state of the connection.
+func listen(url, group string, cfg *config.Config, backends []audio.Backend, clk chrono.Clock) error {
+	execPlayer, queue := newQueue(cfg, backends, clk)
+	defer execPlayer.Close()
+	client, err := broadcast.NewClient(url, queue)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- client.Run(ctx, group) }()
+
+	fmt.Printf("Listening to %s (Ctrl-C to quit)\n", url)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			queue.Stop()
+			fmt.Println()
+			return err
+		case e := <-queue.Events():
+			if e.Kind == audio.EventFailed {
+				fmt.Printf("\r\033[K%s\n", e)
+			}
+		case <-ticker.C:
+			fmt.Printf("\r\033[K%s", renderListenStatus(client.Status(), queue))
+		}
+	}
+}
+
+// renderListenStatus describes the connection and what is playing on one
+// line.
+func renderListenStatus(st broadcast.Status, player audio.Player) string {
+	if !st.Connected {
+		if st.Err != nil {
+			return fmt.Sprintf("\033[31m● Reconnecting: %v\033[0m", st.Err)
+		}
+		return "\033[90m● Connecting…\033[0m"
+	}
+	line := fmt.Sprintf("\033[32m● Connected\033[0m  clock offset %s", st.Offset.Round(time.Millisecond))
+	if player.State() == audio.Playing {
+		line += "  🔊 " + st.Last.Title
+	}
+	return line
+}
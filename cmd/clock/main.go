@@ -1,27 +1,50 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"time"
+	// The zone database is built in for the world clock, as Windows has
+	// none.
+	_ "time/tzdata"
 
 	azanFS "github.com/dadyutenga/upgraded-octo-parakeet/cmd/audio"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/alarm"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/alert"
 	"github.com/dadyutenga/upgraded-octo-parakeet/internal/audio"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/broadcast"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
 	"github.com/dadyutenga/upgraded-octo-parakeet/internal/clock"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/config"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/dashboard"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/notify"
 	"github.com/dadyutenga/upgraded-octo-parakeet/internal/prayer"
-	"github.com/dadyutenga/upgraded-octo-parakeet/internal/stopwatch"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/timer"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/worldclock"
 )
 
 const (
 	ModeClock     = 0
 	ModeStopwatch = 1
 	ModePrayer    = 2
-	ModeCount     = 3
+	ModeAlarms    = 3
+	ModeTimer     = 4
+	ModeWorld     = 5
+	ModeAnalog    = 6
+	ModeCount     = 7
 )
 
-var modeNames = [ModeCount]string{"🕐 Clock", "⏱  Stopwatch", "🕌 Prayer Times"}
+// volumeStep is how much the +/- keys change the volume.
+const volumeStep = 10
+
+var modeNames = [ModeCount]string{"🕐 Clock", "⏱  Stopwatch", "🕌 Prayer Times", "⏰ Alarms", "⏳ Timer", "🌍 World", "🕰  Analog"}
 
 func renderNav(currentMode int) string {
 	nav := "\033[1m"
@@ -38,17 +61,136 @@ func renderNav(currentMode int) string {
 	nav += "\033[0m\n"
 	nav += "  ← → switch modes"
 	if currentMode == ModeStopwatch {
-		nav += "  |  SPACE: start/stop  |  r: reset"
+		nav += "  |  ↑↓: select  |  n: new  |  x: delete  |  SPACE: start/stop  |  l: lap  |  r: reset"
+	}
+	if currentMode == ModeAlarms {
+		nav += "  |  ↑↓: select  |  n: new  |  e: edit  |  x: delete  |  SPACE: on/off"
+	}
+	if currentMode == ModeTimer {
+		nav += "  |  0-9: set  |  SPACE: start/pause  |  m: +1 min  |  r: reset  |  p: Pomodoro"
+	}
+	if currentMode == ModeWorld {
+		nav += "  |  ↑↓: select  |  n: add zone  |  x: remove"
 	}
-	nav += "  |  a: azan on/off  |  s: stop audio"
+	nav += "  |  a: sound on/off  |  s: stop audio  |  +/-: volume"
 	nav += "\n\n"
 	return nav
 }
 
+// newQueue creates the audio player for the azan and the queue that plays
+// through it, set up from cfg, with volumes chosen by the time on clk. The
+// ExecPlayer must be closed when done.
+func newQueue(cfg *config.Config, backends []audio.Backend, clk chrono.Clock) (*audio.ExecPlayer, *audio.Queue) {
+	execPlayer := audio.NewExecPlayerWithClock(backends, clk)
+	execPlayer.SetFades(time.Duration(cfg.Audio.FadeIn), time.Duration(cfg.Audio.FadeOut))
+	execPlayer.SetVolume(cfg.Audio.VolumeAt(azanFS.AzanFile, clk.Now()))
+	queue := audio.NewQueue(execPlayer)
+	queue.SetVolumeFor(func(sound string) int { return cfg.Audio.VolumeAt(sound, clk.Now()) })
+	return execPlayer, queue
+}
+
+// loadBackends returns the audio players configured by the user, or nil for
+// the built-in list.
+func loadBackends(cfg *config.Config) ([]audio.Backend, error) {
+	if len(cfg.Audio.Players) == 0 {
+		return nil, nil
+	}
+	var backends []audio.Backend
+	for _, p := range cfg.Audio.Players {
+		b, err := audio.ParseBackend(p.Command, p.Formats)
+		if err != nil {
+			return nil, fmt.Errorf("audio player %q: %w", p.Command, err)
+		}
+		backends = append(backends, b)
+	}
+	return backends, nil
+}
+
 func main() {
+	defaultConfig, _ := config.DefaultPath()
+	configPath := flag.String("config", defaultConfig, "Path to the JSON config file")
+	broadcastAddr := flag.String("broadcast", "", "Serve the azan to other machines at this address, e.g. :8787")
+	listenURL := flag.String("listen", "", "Play the azan of the clock broadcasting at this URL instead of showing the clock")
+	group := flag.String("multicast", "", "Also announce over this UDP multicast group, e.g. "+broadcast.DefaultGroup)
+	twelveHour := flag.Bool("12h", false, "Show 12-hour time with AM/PM")
+	seconds := flag.Bool("seconds", true, "Show the seconds")
+	leadingZero := flag.Bool("leading-zero", true, "Pad hours below 10 with a zero")
+	blink := flag.Duration("blink", 500*time.Millisecond, "How long the colons stay on and off; 0 keeps them on")
+	fontName := flag.String("font", clock.Block.Name, "Font for the digits: "+strings.Join(clock.FontNames(), ", ")+", or a FIGlet .flf file")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: clock [flags] [diagnose | report [-by day|week] [-csv] [-since date]]")
+		fmt.Fprintln(os.Stderr, "\n  diagnose   report which audio player would play the azan and why")
+		fmt.Fprintln(os.Stderr, "  report     summarize the time logged by the stopwatches")
+		fmt.Fprintln(os.Stderr)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		os.Exit(1)
+	}
+	// Display flags given on the command line override the config file.
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "12h":
+			cfg.Display.TwelveHour = *twelveHour
+		case "seconds":
+			cfg.Display.Seconds = *seconds
+		case "leading-zero":
+			cfg.Display.LeadingZero = *leadingZero
+		case "blink":
+			cfg.Display.Blink = config.Duration(max(0, *blink))
+		}
+	})
+	font, err := clock.LoadFont(*fontName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Font error: %v\n", err)
+		os.Exit(1)
+	}
+	disp := &display{
+		font: font,
+		format: clock.Format{
+			TwelveHour:  cfg.Display.TwelveHour,
+			Seconds:     cfg.Display.Seconds,
+			LeadingZero: cfg.Display.LeadingZero,
+		},
+	}
+	backends, err := loadBackends(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		os.Exit(1)
+	}
+
+	clk := chrono.System
+	switch flag.Arg(0) {
+	case "":
+	case "diagnose":
+		fmt.Print(audio.Diagnose(backends, azanFS.FS, azanFS.AzanFile).Render())
+		return
+	case "report":
+		if err := report(flag.Args()[1:], *configPath, os.Stdout, clk.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "Report error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(1)
+	}
+
 	// Cap memory at 55 MB
 	debug.SetMemoryLimit(55 * 1024 * 1024)
 
+	if *listenURL != "" {
+		if err := listen(*listenURL, *group, cfg, backends, clk); err != nil {
+			fmt.Fprintf(os.Stderr, "Listen error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
 
@@ -56,39 +198,124 @@ func main() {
 	keysCh := make(chan byte, 10)
 	go readKeys(keysCh)
 
-	ticker := time.NewTicker(100 * time.Millisecond)
+	ticker := clk.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
 	showColon := true
-	blinkTick := 0
+	started := clk.Now()
 	currentMode := ModeClock
-	sw := stopwatch.New()
-	azanTriggered := make(map[string]bool) // track which prayers already triggered azan today
+	azan := newAzanTrigger(prayer.GetPrayerTimes)
 	azanEnabled := true
-	var lastDateStr string
 
-	defer audio.Cleanup()
+	execPlayer, queue := newQueue(cfg, backends, clk)
+	defer execPlayer.Close()
+	var player audio.Player = queue
+	sounds := soundFS{builtin: azanFS.FS, dir: os.DirFS(cfg.Audio.SoundDir)}
+	var cast *broadcast.Server
+	var castErrs <-chan error // nil unless broadcasting
+	if *broadcastAddr != "" {
+		var err error
+		if cast, castErrs, err = startBroadcast(*broadcastAddr, *group, sounds); err != nil {
+			fmt.Fprintf(os.Stderr, "Broadcast error: %v\n", err)
+			os.Exit(1)
+		}
+		defer cast.Close()
+	}
+	// stopAudio stops the azan here and in every room listening.
+	stopAudio := func() {
+		player.Stop()
+		if cast != nil {
+			cast.Stop()
+		}
+	}
+	chimes := &audio.ChimeSchedule{Style: cfg.Audio.Chimes.Style, Quarters: cfg.Audio.Chimes.Quarters}
+	history := audio.NewHistory(historySize)
+	notifier := alert.NewNotifier(os.Stdout)
+	alertBanner := &banner{}
+	// Whether any player can play the azan, found once as probing them is
+	// slow.
+	azanAudible := audio.Diagnose(backends, sounds, azanFS.AzanFile).Err == nil
+	unheard := &unheardAzan{}
+	// announce shows a prayer on screen and in the terminal's notifications.
+	announce := func(name string, now time.Time) {
+		alertBanner.show("Azan", fmt.Sprintf("Time for %s prayer", name), now)
+		if err := notifier.Notify("Azan", fmt.Sprintf("Time for %s prayer", name)); err != nil {
+			history.Add(audio.Event{Kind: audio.EventFailed, Sound: "alert", Err: err, Time: now})
+		}
+	}
+	alarmList, err := alarm.Load(filepath.Join(filepath.Dir(*configPath), "alarms.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Alarms error: %v\n", err)
+		os.Exit(1)
+	}
+	alarms := &alarmsView{list: alarmList, disp: disp, clock: clk}
+	stopwatches, err := loadStopwatchView(
+		filepath.Join(filepath.Dir(*configPath), "stopwatch.json"),
+		filepath.Join(filepath.Dir(*configPath), "timesheet.csv"),
+		cfg.Stopwatch, clk)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Stopwatch error: %v\n", err)
+		os.Exit(1)
+	}
+	tally, err := timer.LoadTally(filepath.Join(filepath.Dir(*configPath), "pomodoro.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Pomodoro error: %v\n", err)
+		os.Exit(1)
+	}
+	timers := newTimerView(cfg.Pomodoro, tally, clk)
+	zones, err := worldclock.Load(filepath.Join(filepath.Dir(*configPath), "worldclock.json"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "World clock error: %v\n", err)
+		os.Exit(1)
+	}
+	world := &worldView{list: zones}
+	views := map[int]keyTarget{ModeAlarms: alarms, ModeStopwatch: stopwatches, ModeTimer: timers, ModeWorld: world}
+	desk := newDesktop(cfg.Alerts.Desktop)
+	stopAzan := notify.Action{Key: "stop", Label: "Stop azan", Do: stopAudio}
+
+	disp.resize()
+	resized := make(chan struct{}, 1)
+	watchResize(resized)
 
 	fmt.Print("\033[2J\033[H\033[?25l") // clear screen, hide cursor
-	render(currentMode, showColon, sw, azanEnabled)
+	render(clk.Now(), currentMode, disp, showColon, stopwatches, azanEnabled, player, history, chimes, alertBanner, alarms, timers, world)
 
 	for {
 		select {
 		case <-sig:
-			audio.Stop()
-			fmt.Print("\033[?25h") // show cursor
-			fmt.Println("\nGoodbye!")
+			player.Stop()
+			goodbye(stopwatches, clk.Now())
 			return
+		case e := <-player.Events():
+			history.Add(e)
+			if name := unheard.event(e); name != "" {
+				announce(name, clk.Now())
+			}
+			fmt.Print("\033[2J\033[H")
+			render(clk.Now(), currentMode, disp, showColon, stopwatches, azanEnabled, player, history, chimes, alertBanner, alarms, timers, world)
+		case <-resized:
+			disp.resize()
+			fmt.Print("\033[2J\033[H")
+			render(clk.Now(), currentMode, disp, showColon, stopwatches, azanEnabled, player, history, chimes, alertBanner, alarms, timers, world)
+		case err := <-desk.Errors():
+			history.Add(audio.Event{Kind: audio.EventFailed, Sound: "notification", Err: err, Time: clk.Now()})
+		case err := <-castErrs:
+			history.Add(audio.Event{Kind: audio.EventFailed, Sound: "broadcast", Err: err, Time: clk.Now()})
 		case key := <-keysCh:
+			if alarms.ringing != nil {
+				alarms.answer(key, clk.Now(), player, sounds)
+				key = 0
+			} else {
+				key = routeKey(key, clk.Now(), alertBanner, views[currentMode], stopAudio)
+			}
 			switch key {
 			case 'q', 'Q':
-				audio.Stop()
-				fmt.Print("\033[?25h")
-				fmt.Println("\nGoodbye!")
+				player.Stop()
+				goodbye(stopwatches, clk.Now())
 				return
-			case 'D': // left arrow (escape seq handled in readKeys)
+			case keyLeft:
 				currentMode = (currentMode - 1 + ModeCount) % ModeCount
-			case 'C': // right arrow
+			case keyRight:
 				currentMode = (currentMode + 1) % ModeCount
 			case '1':
 				currentMode = ModeClock
@@ -96,95 +323,368 @@ func main() {
 				currentMode = ModeStopwatch
 			case '3':
 				currentMode = ModePrayer
-			case ' ':
-				if currentMode == ModeStopwatch {
-					sw.Toggle()
-				}
-			case 'r', 'R':
-				if currentMode == ModeStopwatch {
-					sw.Reset()
-				}
+			case '4':
+				currentMode = ModeAlarms
+			case '5':
+				currentMode = ModeTimer
+			case '6':
+				currentMode = ModeWorld
+			case '7':
+				currentMode = ModeAnalog
 			case 'a', 'A':
 				azanEnabled = !azanEnabled
 			case 's', 'S':
-				audio.Stop()
+				stopAudio()
+			case '+', '=':
+				player.SetVolume(player.Volume() + volumeStep)
+			case '-', '_':
+				player.SetVolume(player.Volume() - volumeStep)
 			}
 			fmt.Print("\033[2J\033[H")
-			render(currentMode, showColon, sw, azanEnabled)
-		case <-ticker.C:
-			blinkTick++
-			if blinkTick%5 == 0 { // blink every 500ms
-				showColon = !showColon
+			render(clk.Now(), currentMode, disp, showColon, stopwatches, azanEnabled, player, history, chimes, alertBanner, alarms, timers, world)
+		case <-ticker.C():
+			now := clk.Now()
+			if blink := time.Duration(cfg.Display.Blink); blink > 0 {
+				showColon = now.Sub(started)/blink%2 == 0
 			}
 
 			// Check for azan trigger
-			now := time.Now()
-			dateStr := now.Format("2006-01-02")
-			if dateStr != lastDateStr {
-				azanTriggered = make(map[string]bool)
-				lastDateStr = dateStr
-			}
 			if azanEnabled {
-				checkAzan(now, azanTriggered)
+				name, err := checkAzan(now, azan, queue, cast, sounds, cfg.Audio)
+				if err != nil {
+					history.Add(audio.Event{Kind: audio.EventFailed, Sound: azanFS.AzanFile, Err: err, Time: now})
+					fmt.Print("\033[2J")
+				}
+				if name != "" {
+					desk.notify("Azan", fmt.Sprintf("Time for %s prayer", name), notify.Critical, stopAzan)
+					switch {
+					case wantVisualAlert(cfg.Alerts.Visual, azanAudible && err == nil):
+						announce(name, now)
+					case cfg.Alerts.Visual == config.VisualAuto:
+						unheard.prayer = name
+					}
+				}
+			}
+			if name, at := azan.reminder(now, time.Duration(cfg.Alerts.RemindBefore)); name != "" {
+				mins := int((at.Sub(now) + time.Minute - 1) / time.Minute)
+				desk.notify("Prayer reminder", fmt.Sprintf("%s at %s, in %d min", name, at.Format("15:04"), mins), notify.Normal)
+			}
+			if title, message, ok := timers.expired(now); ok {
+				// Like chimes, the timer's sound is muted with the azan
+				// and kept from cutting into an alarm.
+				if azanEnabled && alarms.ringing == nil {
+					queue.PlaySequence(context.Background(), audio.ChimeFS{}, timerSound)
+				}
+				alertBanner.show(title, message, now)
+				desk.notify(title, message, notify.Normal)
+			}
+
+			wasRinging := alarms.ringing != nil
+			if a := alarms.check(now); a != nil {
+				desk.notify("Alarm", a.Label, notify.Critical)
+				fmt.Print("\033[2J")
+			}
+			alarms.play(now, player, sounds)
+			if wasRinging && alarms.ringing == nil {
+				fmt.Print("\033[2J")
+			}
+
+			// Chimes share the azan's mute and never interrupt it or an alarm.
+			if seq, ok := chimes.Due(now); ok && azanEnabled && alarms.ringing == nil && queue.State() == audio.Idle {
+				queue.PlaySequence(context.Background(), audio.ChimeFS{}, seq)
+			}
+			// The broadcast ends when everything queued has played.
+			if cast != nil && queue.State() == audio.Idle {
+				cast.Finish()
 			}
 
+			if alertBanner.expired(now) {
+				fmt.Print("\033[2J")
+			}
 			fmt.Print("\033[H")
-			render(currentMode, showColon, sw, azanEnabled)
+			render(now, currentMode, disp, showColon, stopwatches, azanEnabled, player, history, chimes, alertBanner, alarms, timers, world)
 		}
 	}
 }
 
-// checkAzan triggers the azan if we're within 1 minute of a prayer time.
-func checkAzan(now time.Time, triggered map[string]bool) {
-	prayers, err := prayer.GetPrayerTimes(now)
-	if err != nil || len(prayers) == 0 {
-		return
+// keyTarget is a mode's view that takes key presses, reporting whether it
+// used them.
+type keyTarget interface {
+	key(key byte) bool
+}
+
+// routeKey gives a key to what is in front: an alert banner, which any key
+// dismisses and 's' also stops the azan with, or else the current mode's
+// view, if it has one. It returns the key if neither used it, or 0.
+func routeKey(key byte, now time.Time, alertBanner *banner, view keyTarget, stopAudio func()) byte {
+	if alertBanner.active(now) {
+		alertBanner.dismiss()
+		if key == 's' || key == 'S' {
+			stopAudio()
+		}
+		return 0
+	}
+	if view != nil && view.key(key) {
+		return 0
+	}
+	return key
+}
+
+// goodbye restores the terminal before the clock quits, keeping the
+// stopwatches for the next run and exporting their laps as of now.
+func goodbye(stopwatches *stopwatchView, now time.Time) {
+	fmt.Print("\033[?25h") // show cursor
+	fmt.Println("\nGoodbye!")
+	if err := stopwatches.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not save the stopwatch: %v\n", err)
+	}
+	paths, err := stopwatches.exportAll(now)
+	for _, path := range paths {
+		fmt.Printf("Laps saved to %s\n", path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not save laps: %v\n", err)
+	}
+}
+
+// azanTrigger decides when each prayer's azan and reminder are due. Each
+// is due once a day, within a minute of its time.
+type azanTrigger struct {
+	times     func(date time.Time) ([]prayer.PrayerTime, error)
+	day       string
+	triggered map[string]bool // prayers whose azan was due today
+	reminded  map[string]bool // and those reminded of
+}
+
+// newAzanTrigger creates a trigger for the prayer times given by times,
+// prayer.GetPrayerTimes outside tests.
+func newAzanTrigger(times func(date time.Time) ([]prayer.PrayerTime, error)) *azanTrigger {
+	return &azanTrigger{times: times}
+}
+
+// rollover forgets the previous day's prayers once the date changes.
+func (t *azanTrigger) rollover(now time.Time) {
+	if day := now.Format("2006-01-02"); day != t.day {
+		t.day = day
+		t.triggered = make(map[string]bool)
+		t.reminded = make(map[string]bool)
+	}
+}
+
+// due returns the prayer whose azan is due at now, if any.
+func (t *azanTrigger) due(now time.Time) (string, bool) {
+	t.rollover(now)
+	prayers, err := t.times(now)
+	if err != nil {
+		return "", false
 	}
 	// Only trigger for actual prayer times (skip Sunrise)
 	for _, p := range prayers {
-		if p.Name == "Sunrise" {
+		if p.Name == "Sunrise" || t.triggered[p.Name] {
 			continue
 		}
-		if triggered[p.Name] {
+		diff := now.Sub(p.Time)
+		if diff >= 0 && diff < time.Minute {
+			t.triggered[p.Name] = true
+			return p.Name, true
+		}
+	}
+	return "", false
+}
+
+// reminder returns the prayer, and its time, that is due a reminder
+// because it is the given duration away.
+func (t *azanTrigger) reminder(now time.Time, before time.Duration) (string, time.Time) {
+	if before <= 0 {
+		return "", time.Time{}
+	}
+	t.rollover(now)
+	prayers, err := t.times(now)
+	if err != nil {
+		return "", time.Time{}
+	}
+	for _, p := range prayers {
+		if p.Name == "Sunrise" || t.reminded[p.Name] {
 			continue
 		}
-		diff := now.Sub(p.Time)
+		diff := now.Sub(p.Time.Add(-before))
 		if diff >= 0 && diff < time.Minute {
-			triggered[p.Name] = true
-			audio.Play(azanFS.FS, azanFS.AzanFile)
-			return
+			t.reminded[p.Name] = true
+			return p.Name, p.Time
 		}
 	}
+	return "", time.Time{}
 }
 
-func render(mode int, showColon bool, sw *stopwatch.Stopwatch, azanEnabled bool) {
-	fmt.Print(renderNav(mode))
+// checkAzan queues the azan, or the sequence configured for the prayer, if
+// one is due at now, announcing it to listening clocks if cast is set. It
+// returns the name of the prayer triggered, if any, and the error from
+// starting playback.
+func checkAzan(now time.Time, azan *azanTrigger, queue *audio.Queue, cast *broadcast.Server, sounds fs.FS, audioCfg config.Audio) (string, error) {
+	name, ok := azan.due(now)
+	if !ok {
+		return "", nil
+	}
+	seq := azanSequence(audioCfg, name)
+	if cast != nil {
+		seq = cast.Play(name, seq)
+	}
+	return name, queue.PlaySequence(context.Background(), sounds, seq)
+}
+
+// wantVisualAlert reports whether a prayer should also be announced without
+// sound: always, or in auto mode when the azan cannot be played.
+func wantVisualAlert(mode string, audible bool) bool {
+	switch mode {
+	case config.VisualAlways:
+		return true
+	case config.VisualAuto:
+		return !audible
+	default:
+		return false
+	}
+}
+
+// unheardAzan is the prayer whose azan is playing in auto mode, to be
+// announced without sound if the azan fails.
+type unheardAzan struct {
+	prayer string
+}
+
+// event takes an event from the player and returns the prayer to announce
+// if the azan failed. Any other end to the azan forgets the prayer.
+func (u *unheardAzan) event(e audio.Event) string {
+	if u.prayer == "" || e.Kind == audio.EventStarted {
+		return ""
+	}
+	name := u.prayer
+	u.prayer = ""
+	if e.Kind != audio.EventFailed {
+		return ""
+	}
+	return name
+}
+
+// azanSequence returns what to play for a prayer: its configured sequence,
+// or just the azan.
+func azanSequence(audioCfg config.Audio, prayerName string) audio.Sequence {
+	steps := audioCfg.SequenceFor(prayerName)
+	if len(steps) == 0 {
+		return audio.Sequence{{Sound: azanFS.AzanFile}}
+	}
+	seq := make(audio.Sequence, len(steps))
+	for i, st := range steps {
+		seq[i] = audio.Step{Sound: st.Sound, Gap: time.Duration(st.Gap)}
+	}
+	return seq
+}
+
+// render draws the screen for mode as of now.
+func render(now time.Time, mode int, disp *display, showColon bool, stopwatches *stopwatchView, azanEnabled bool, player audio.Player, history *audio.History, chimes *audio.ChimeSchedule, alertBanner *banner, alarms *alarmsView, timers *timerView, world *worldView) {
+	if alarms.ringing != nil {
+		fmt.Print(alarms.renderRinging(now, showColon))
+		return
+	}
+	if alertBanner.active(now) {
+		width, height := disp.screen()
+		fmt.Print(alert.Banner(alertBanner.title, alertBanner.message, width, height, showColon))
+		return
+	}
+	header := renderNav(mode)
+	if last, ok := history.Last(); ok && last.Kind == audio.EventFailed {
+		header += fmt.Sprintf("  \033[1;31m⚠ %s\033[0m\033[K\n\n", last)
+	}
+	fmt.Print(header)
+	// rows is what is left of the screen, keeping the last line free so
+	// that it does not scroll.
+	rows := disp.height - lineCount(header)
 
 	switch mode {
 	case ModeClock:
-		fmt.Println(clock.RenderTime(time.Now(), showColon))
-		fmt.Printf("\n  %s\n", time.Now().Format("Monday, 02 January 2006"))
+		below := fmt.Sprintf("\n  %s\n", now.Format("Monday, 02 January 2006"))
+		if chimes.Style != "" {
+			every := "hourly"
+			if chimes.Quarters {
+				every = "every quarter hour"
+			}
+			if azanEnabled {
+				below += fmt.Sprintf("\n  \033[32m🔔 Chimes: %s, %s\033[0m\033[K\n", chimes.Style, every)
+			} else {
+				below += "\n  \033[90m🔕 Chimes: OFF\033[0m\033[K\n"
+			}
+		}
+		rows -= lineCount(below) - 1
+		digits := disp.digits(func(f *clock.Font) string {
+			return f.RenderTime(now, showColon, disp.format)
+		}, clock.TimeText(now, disp.format), rows)
+		fmt.Println(middle(digits, rows))
+		fmt.Print(below)
 	case ModeStopwatch:
-		elapsed := sw.Elapsed()
-		fmt.Println(clock.RenderDuration(elapsed, showColon))
-		ms := elapsed.Milliseconds() % 1000
-		status := "\033[31m⏸ Stopped\033[0m"
-		if sw.IsRunning() {
-			status = "\033[32m● Running\033[0m"
-		}
-		fmt.Printf("\n  .%03d   %s\n", ms, status)
+		fmt.Print(stopwatches.render(disp, showColon, rows))
 	case ModePrayer:
-		now := time.Now()
 		prayers, err := prayer.GetPrayerTimes(now)
 		fmt.Println(prayer.Render(prayers, now, err))
 		if azanEnabled {
-			fmt.Println("  \033[32m🔊 Azan: ON\033[0m")
+			fmt.Printf("  \033[32m🔊 Azan: ON\033[0m   Volume: %d%%\033[K\n", player.Volume())
 		} else {
 			fmt.Println("  \033[90m🔇 Azan: OFF\033[0m")
 		}
-		if audio.IsPlaying() {
-			fmt.Println("  \033[33m♪ Playing azan... (press 's' to stop)\033[0m")
+		if player.State() == audio.Playing {
+			fmt.Println(renderPlayback(player.Progress()))
 		}
+		fmt.Print(renderHistory(history.Events()))
+	case ModeAlarms:
+		fmt.Print(alarms.render(now))
+	case ModeTimer:
+		fmt.Print(timers.render(now, disp, showColon, rows))
+	case ModeWorld:
+		fmt.Print(world.render(now, disp, showColon, rows))
+	case ModeAnalog:
+		below := fmt.Sprintf("\n  %s\n", now.Format("Monday, 02 January 2006"))
+		rows -= lineCount(below) - 1
+		fmt.Println(middle(disp.analog(now, rows), rows))
+		fmt.Print(below)
 	}
 }
 
+// historySize is how many playback events are kept for display.
+const historySize = 5
+
+// renderHistory lists recent playback events, newest first.
+func renderHistory(events []audio.Event) string {
+	if len(events) == 0 {
+		return ""
+	}
+	out := "\n  \033[90mRecent audio:\033[0m\n"
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		out += fmt.Sprintf("  \033[90m%s  %s\033[0m\033[K\n", e.Time.Format("15:04:05"), e)
+	}
+	return out
+}
+
+// playbackBarWidth is the width of the playback progress bar.
+const playbackBarWidth = 30
+
+// renderPlayback shows which sound is playing, or the pause between
+// sounds, and how far it has got.
+func renderPlayback(p audio.Progress) string {
+	what := "Playing " + p.Sound
+	if p.Sound == "" {
+		what = "Pause before the next sound"
+	}
+	line := fmt.Sprintf("  \033[33m♪ %s (press 's' to stop)\033[0m\033[K\n  ", what)
+	if p.Duration > 0 {
+		line += fmt.Sprintf("%s %s / %s", dashboard.ProgressBar(p.Fraction()*100, playbackBarWidth),
+			formatMinutes(p.Position), formatMinutes(p.Duration))
+	} else {
+		line += formatMinutes(p.Position)
+	}
+	return line + "\033[K"
+}
+
+// formatMinutes formats d as M:SS.
+func formatMinutes(d time.Duration) string {
+	s := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/alarm"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/audio"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/clock"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/config"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/prayer"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/timer"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/worldclock"
+)
+
+// fakeTimes returns the same prayer times every day: Isha a moment before
+// midnight and Fajr just after it.
+func fakeTimes(date time.Time) ([]prayer.PrayerTime, error) {
+	at := func(h, m, s int) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), h, m, s, 0, date.Location())
+	}
+	return []prayer.PrayerTime{
+		{Name: "Fajr", Time: at(0, 0, 20)},
+		{Name: "Sunrise", Time: at(6, 0, 0)},
+		{Name: "Isha", Time: at(23, 59, 30)},
+	}, nil
+}
+
+// tickUntil runs the clock loop's azan check every 100ms until end,
+// returning the prayers triggered in order.
+func tickUntil(clk *chrono.Fake, trigger *azanTrigger, end time.Time) []string {
+	var fired []string
+	ticker := clk.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for clk.Now().Before(end) {
+		clk.Advance(100 * time.Millisecond)
+		now := <-ticker.C()
+		if name, ok := trigger.due(now); ok {
+			fired = append(fired, name)
+		}
+	}
+	return fired
+}
+
+func TestAzanTrigger_DayRollover(t *testing.T) {
+	start := time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC)
+	clk := chrono.NewFake(start)
+	trigger := newAzanTrigger(fakeTimes)
+
+	fired := tickUntil(clk, trigger, start.Add(2*time.Minute))
+	if len(fired) != 2 || fired[0] != "Isha" || fired[1] != "Fajr" {
+		t.Fatalf("expected Isha then Fajr across midnight, got %v", fired)
+	}
+
+	// The next night, Isha is due again, once.
+	clk.Set(time.Date(2026, 10, 19, 23, 59, 0, 0, time.UTC))
+	if fired := tickUntil(clk, trigger, clk.Now().Add(time.Minute)); len(fired) != 1 || fired[0] != "Isha" {
+		t.Errorf("expected Isha once the next night, got %v", fired)
+	}
+}
+
+func TestAzanTrigger_Reminder(t *testing.T) {
+	trigger := newAzanTrigger(fakeTimes)
+	now := time.Date(2026, 10, 18, 23, 49, 40, 0, time.UTC)
+	name, at := trigger.reminder(now, 10*time.Minute)
+	if name != "Isha" || at.Format("15:04:05") != "23:59:30" {
+		t.Errorf("reminder = %q at %v, want Isha", name, at)
+	}
+	if name, _ := trigger.reminder(now.Add(time.Second), 10*time.Minute); name != "" {
+		t.Errorf("expected one reminder, got another for %q", name)
+	}
+	if name, _ := trigger.reminder(now, 0); name != "" {
+		t.Error("expected no reminders when they are off")
+	}
+}
+
+func TestUnheardAzan(t *testing.T) {
+	u := &unheardAzan{prayer: "Asr"}
+	if name := u.event(audio.Event{Kind: audio.EventStarted}); name != "" {
+		t.Errorf("expected nothing announced as the azan starts, got %q", name)
+	}
+	if name := u.event(audio.Event{Kind: audio.EventFailed}); name != "Asr" {
+		t.Errorf("expected Asr announced when the azan fails, got %q", name)
+	}
+	if name := u.event(audio.Event{Kind: audio.EventFailed}); name != "" {
+		t.Errorf("expected Asr announced once, got %q", name)
+	}
+
+	u.prayer = "Maghrib"
+	u.event(audio.Event{Kind: audio.EventFinished})
+	if name := u.event(audio.Event{Kind: audio.EventFailed}); name != "" {
+		t.Errorf("expected a later failure not to announce a heard azan, got %q", name)
+	}
+}
+
+func TestSendKeys(t *testing.T) {
+	ch := make(chan byte, 16)
+	sendKeys(ch, []byte("A\x1b[A\x1b[D\x1b[Hq\xc3\xa9"))
+	close(ch)
+	var got []byte
+	for k := range ch {
+		got = append(got, k)
+	}
+	if want := []byte{'A', keyUp, keyLeft, 'q'}; string(got) != string(want) {
+		t.Errorf("expected keys %v, got %v", want, got)
+	}
+}
+
+func TestTimerView_Countdown(t *testing.T) {
+	clk := chrono.NewFake(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+	tally, err := timer.LoadTally(filepath.Join(t.TempDir(), "pomodoro.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := config.Pomodoro{
+		Work:       config.Duration(25 * time.Minute),
+		ShortBreak: config.Duration(5 * time.Minute),
+		LongBreak:  config.Duration(15 * time.Minute),
+		LongEvery:  4,
+	}
+	v := newTimerView(settings, tally, clk)
+	v.key('p')
+	v.key(' ')
+
+	clk.Advance(25*time.Minute - time.Second)
+	if _, _, ok := v.expired(clk.Now()); ok {
+		t.Fatal("expired a second early")
+	}
+	clk.Advance(time.Second)
+	title, message, ok := v.expired(clk.Now())
+	if !ok || title != "Pomodoro" || message != "Work done. Next: short break, 5 min" {
+		t.Errorf("expired = %q, %q, %v", title, message, ok)
+	}
+	if tally.Today(clk.Now()) != 1 {
+		t.Errorf("expected the finished work session to be counted, got %d", tally.Today(clk.Now()))
+	}
+	if v.timer.Remaining() != 5*time.Minute || v.timer.IsRunning() {
+		t.Errorf("expected a stopped 5-minute break next, got %v", v.timer.Remaining())
+	}
+}
+
+func TestWorldView_Keys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worldclock.json")
+	zones, err := worldclock.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &worldView{list: zones}
+	typeKeys := func(s string) {
+		for i := 0; i < len(s); i++ {
+			v.key(s[i])
+		}
+	}
+	typeKeys("nAsia/Tokyo\r\r")
+	typeKeys("nEurope/Paris\tOffice\r")
+	if v.form != nil || len(zones.Zones) != 2 || zones.Zones[1].Label != "Office" || v.selected != 1 {
+		t.Fatalf("expected two zones with the second selected, got %+v, selected %d", zones.Zones, v.selected)
+	}
+	typeKeys("nNowhere\r\r")
+	if v.form == nil || v.form.err == nil {
+		t.Fatal("expected the form to stay open with an error for an unknown zone")
+	}
+	v.key(0x1b)
+
+	typeKeys(string([]byte{keyUp, 'x'}))
+	loaded, err := worldclock.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Zones) != 1 || loaded.Zones[0].Label != "Office" {
+		t.Errorf("expected Tokyo to be removed and saved, got %+v", loaded.Zones)
+	}
+}
+
+func TestRouteKey_BannerOverTimer(t *testing.T) {
+	clk := chrono.NewFake(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+	tally, err := timer.LoadTally(filepath.Join(t.TempDir(), "pomodoro.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newTimerView(config.Default().Pomodoro, tally, clk)
+	v.key(' ')
+	clk.Advance(defaultTimer)
+	title, message, ok := v.expired(clk.Now())
+	if !ok {
+		t.Fatal("expected the timer to expire")
+	}
+	alertBanner := &banner{}
+	alertBanner.show(title, message, clk.Now())
+
+	stopped := false
+	if key := routeKey(' ', clk.Now(), alertBanner, v, func() { stopped = true }); key != 0 {
+		t.Errorf("expected the banner to take the key, got %q", key)
+	}
+	if alertBanner.active(clk.Now()) {
+		t.Error("expected SPACE to dismiss the banner")
+	}
+	if v.timer.IsRunning() || stopped {
+		t.Error("expected the key not to reach the timer behind the banner, nor stop the audio")
+	}
+
+	alertBanner.show(title, message, clk.Now())
+	routeKey('s', clk.Now(), alertBanner, v, func() { stopped = true })
+	if !stopped || alertBanner.active(clk.Now()) {
+		t.Error("expected 's' to dismiss the banner and stop the audio")
+	}
+	if routeKey(' ', clk.Now(), alertBanner, v, nil) != 0 || !v.timer.IsRunning() {
+		t.Error("expected SPACE to start the timer once the banner is gone")
+	}
+	if routeKey('q', clk.Now(), alertBanner, nil, nil) != 'q' {
+		t.Error("expected keys no view uses to be returned")
+	}
+}
+
+func TestStopwatchView_ResetKeepsLapsIfExportFails(t *testing.T) {
+	dir := t.TempDir()
+	clk := chrono.NewFake(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+	// A file where the export directory should be makes exporting fail.
+	blocked := filepath.Join(dir, "laps")
+	if err := os.WriteFile(blocked, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	settings := config.Stopwatch{Export: config.ExportCSV, ExportDir: blocked}
+	v, err := loadStopwatchView(filepath.Join(dir, "stopwatch.json"), filepath.Join(dir, "timesheet.csv"), settings, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.key(' ')
+	clk.Advance(time.Minute)
+	v.key('l')
+	v.key('r')
+	w, _ := v.current()
+	if v.err == nil {
+		t.Error("expected the failed export to be shown")
+	}
+	if len(w.Laps()) != 1 || w.Elapsed() != time.Minute {
+		t.Errorf("expected the stopwatch to keep its laps, got %d laps and %v", len(w.Laps()), w.Elapsed())
+	}
+
+	v.settings.ExportDir = filepath.Join(dir, "saved")
+	v.key('r')
+	if v.err != nil || v.saved == "" || len(w.Laps()) != 0 {
+		t.Errorf("expected the laps to be exported and reset, got %v, %q", v.err, v.saved)
+	}
+}
+
+func TestStopwatchView_ExportAll(t *testing.T) {
+	dir := t.TempDir()
+	clk := chrono.NewFake(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+	settings := config.Stopwatch{Export: config.ExportJSON, ExportDir: filepath.Join(dir, "laps")}
+	v, err := loadStopwatchView(filepath.Join(dir, "stopwatch.json"), filepath.Join(dir, "timesheet.csv"), settings, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.key(' ')
+	clk.Advance(time.Minute)
+	v.key('l')
+	for _, k := range "nDeploy\r" {
+		v.key(byte(k))
+	}
+
+	paths, err := v.exportAll(clk.Now())
+	if err != nil || len(paths) != 1 || filepath.Ext(paths[0]) != ".json" {
+		t.Fatalf("expected the one stopwatch with laps to be exported, got %v, %v", paths, err)
+	}
+	if w, _ := v.current(); w.Name != "Deploy" {
+		t.Fatalf("expected the new stopwatch to be selected, got %q", w.Name)
+	}
+	v.settings.Export = config.ExportOff
+	if paths, err := v.exportAll(clk.Now()); err != nil || len(paths) != 0 {
+		t.Errorf("expected nothing exported with exports off, got %v, %v", paths, err)
+	}
+}
+
+func TestAlarmsView_NewAlarmAtClockTime(t *testing.T) {
+	v := &alarmsView{list: &alarm.List{}, clock: chrono.NewFake(time.Date(2026, 10, 18, 6, 45, 0, 0, time.UTC))}
+	v.key('n')
+	if v.form == nil || v.form.fields[1] != "06:45" {
+		t.Fatalf("expected a new alarm at the clock's 06:45, got %+v", v.form)
+	}
+}
+
+// cells returns how many terminal cells s takes, counting emoji and East
+// Asian wide characters as two.
+func cells(s string) int {
+	n := 0
+	for _, r := range s {
+		switch {
+		case r >= 0x1100 && r <= 0x115f, r == 0x231a, r == 0x231b,
+			r >= 0x23e9 && r <= 0x23ec, r == 0x23f0, r == 0x23f3,
+			r >= 0x2e80 && r <= 0xa4cf, r >= 0xac00 && r <= 0xd7a3,
+			r >= 0xf900 && r <= 0xfaff, r >= 0xff00 && r <= 0xff60,
+			r >= 0x1f300 && r <= 0x1faff:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+func TestRenderRinging_FillsTheTerminal(t *testing.T) {
+	ansi := regexp.MustCompile("\033\\[[0-9;]*[A-Za-z]")
+	now := time.Date(2026, 10, 18, 6, 45, 0, 0, time.UTC)
+	for _, size := range [][2]int{{160, 40}, {0, 0}} {
+		disp := &display{font: clock.Wide, format: clock.Format{Seconds: true, LeadingZero: true}, width: size[0], height: size[1]}
+		v := &alarmsView{disp: disp, ringing: &alarm.Alarm{Label: "Wake up"}}
+		width, height := disp.screen()
+		rows := strings.Split(ansi.ReplaceAllString(v.renderRinging(now, true), ""), "\n")
+		if len(rows) != height {
+			t.Errorf("%v: expected %d rows, got %d", size, height, len(rows))
+		}
+		for i, row := range rows {
+			if n := cells(row); n != width {
+				t.Fatalf("%v: row %d is %d columns wide, want %d", size, i, n, width)
+			}
+		}
+		if !strings.Contains(strings.Join(rows, "\n"), "WAKE UP") {
+			t.Errorf("%v: expected the alarm's label", size)
+		}
+	}
+}
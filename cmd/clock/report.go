@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/stopwatch"
+)
+
+// report prints the time logged by the named stopwatches kept next to
+// configPath, per name per day or week, up to now.
+func report(args []string, configPath string, w io.Writer, now time.Time) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	by := fs.String("by", "day", "Group time by day or week")
+	asCSV := fs.Bool("csv", false, "Write CSV instead of a table")
+	since := fs.String("since", "", "Only count time from this date on, e.g. 2026-10-01")
+	if err := fs.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	period, err := stopwatch.ParsePeriod(*by)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(configPath)
+	timesheet := filepath.Join(dir, "timesheet.csv")
+	intervals, err := stopwatch.ReadLog(timesheet)
+	if err != nil {
+		return err
+	}
+	// Count the stopwatches still running up to now.
+	group, err := stopwatch.LoadGroup(filepath.Join(dir, "stopwatch.json"), timesheet)
+	if err != nil {
+		return err
+	}
+	intervals = append(intervals, group.Running(now)...)
+
+	if *since != "" {
+		from, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid -since %q: %w", *since, err)
+		}
+		intervals = clip(intervals, from)
+	}
+
+	rows := stopwatch.Summarize(intervals, period, time.Local)
+	if *asCSV {
+		return stopwatch.WriteReportCSV(w, rows, period)
+	}
+	if len(rows) == 0 {
+		_, err := fmt.Fprintln(w, "No time logged yet.")
+		return err
+	}
+	return stopwatch.WriteReport(w, rows, period)
+}
+
+// clip drops the time in intervals before from.
+func clip(intervals []stopwatch.Interval, from time.Time) []stopwatch.Interval {
+	var kept []stopwatch.Interval
+	for _, iv := range intervals {
+		if !iv.End.After(from) {
+			continue
+		}
+		if iv.Start.Before(from) {
+			iv.Start = from
+		}
+		kept = append(kept, iv)
+	}
+	return kept
+}
@@ -0,0 +1,18 @@
+package main
+
+import "io/fs"
+
+// soundFS finds sounds among the built-in ones first, then in the user's
+// sound directory.
+type soundFS struct {
+	builtin fs.FS
+	dir     fs.FS
+}
+
+// Open implements fs.FS.
+func (s soundFS) Open(name string) (fs.File, error) {
+	if f, err := s.builtin.Open(name); err == nil {
+		return f, nil
+	}
+	return s.dir.Open(name)
+}
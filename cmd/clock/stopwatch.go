@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/clock"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/config"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/stopwatch"
+)
+
+// lapRows is how many laps the lap table shows, newest first.
+const lapRows = 8
+
+// stopwatchView is the Stopwatch mode: named stopwatches and their laps.
+type stopwatchView struct {
+	group    *stopwatch.Group
+	selected int
+	naming   *string // name typed for a new stopwatch; nil unless adding one
+	settings config.Stopwatch
+	clock    chrono.Clock
+	saved    string // where the laps were last exported
+	err      error  // from the last export, save or log
+}
+
+// loadStopwatchView restores the stopwatches kept at path, which go on
+// running if they were running when the clock last quit. Each time one
+// stops, the time it ran by clk is logged to timesheet.
+func loadStopwatchView(path, timesheet string, settings config.Stopwatch, clk chrono.Clock) (*stopwatchView, error) {
+	group, err := stopwatch.LoadGroupWithClock(path, timesheet, clk)
+	if err != nil {
+		return nil, err
+	}
+	group.Exclusive = settings.Exclusive
+	return &stopwatchView{group: group, settings: settings, clock: clk}, nil
+}
+
+// save keeps the stopwatches for the next run.
+func (v *stopwatchView) save() error {
+	if err := v.group.Save(); err != nil {
+		v.err = err
+		return err
+	}
+	return nil
+}
+
+// current returns the selected stopwatch, if there are any.
+func (v *stopwatchView) current() (stopwatch.Named, bool) {
+	watches := v.group.Watches()
+	if v.selected < 0 || v.selected >= len(watches) {
+		return stopwatch.Named{}, false
+	}
+	return watches[v.selected], true
+}
+
+// key handles a key press in Stopwatch mode, reporting whether it was used.
+func (v *stopwatchView) key(key byte) bool {
+	if v.naming != nil {
+		v.nameKey(key)
+		return true
+	}
+	if key == 'n' || key == 'N' {
+		name := ""
+		v.naming, v.err = &name, nil
+		return true
+	}
+	w, ok := v.current()
+	if !ok {
+		return false
+	}
+	var err error
+	switch key {
+	case keyUp:
+		v.selected = max(0, v.selected-1)
+		return true
+	case keyDown:
+		v.selected = min(len(v.group.Watches())-1, v.selected+1)
+		return true
+	case ' ':
+		err = v.group.Toggle(w.Name)
+	case 'l', 'L', '\r', '\n':
+		w.Lap()
+	case 'r', 'R':
+		// Keep the laps unless they were saved.
+		if _, err := v.export(w, v.clock.Now()); err != nil {
+			v.err = err
+			return true
+		}
+		err = v.group.Reset(w.Name)
+	case 'x', 'X':
+		err = v.group.Remove(w.Name)
+		v.selected = max(0, min(v.selected, len(v.group.Watches())-1))
+	default:
+		return false
+	}
+	v.err = err
+	v.save()
+	return true
+}
+
+// nameKey edits the name of a new stopwatch. ENTER adds it and ESC
+// cancels.
+func (v *stopwatchView) nameKey(key byte) {
+	switch {
+	case key == 0x1b:
+		v.naming = nil
+	case key == '\r' || key == '\n':
+		if v.err = v.group.Add(*v.naming); v.err != nil {
+			return
+		}
+		v.naming = nil
+		v.selected = len(v.group.Watches()) - 1
+		v.save()
+	case key == 0x7f || key == 0x08: // backspace
+		if r := []rune(*v.naming); len(r) > 0 {
+			*v.naming = string(r[:len(r)-1])
+		}
+	case key >= 0x20 && key < 0x7f:
+		*v.naming += string(rune(key))
+	}
+}
+
+// export saves w's laps, if there are any and exporting is on, and
+// returns the file written.
+func (v *stopwatchView) export(w stopwatch.Named, now time.Time) (string, error) {
+	laps := w.Laps()
+	if len(laps) == 0 || v.settings.Export == config.ExportOff {
+		return "", nil
+	}
+	v.saved, v.err = stopwatch.Export(v.settings.ExportDir, v.settings.Export, w.Name, laps, now)
+	return v.saved, v.err
+}
+
+// exportAll saves the laps of every stopwatch that has some, if exporting
+// is on, and returns the files written.
+func (v *stopwatchView) exportAll(now time.Time) ([]string, error) {
+	var paths []string
+	for _, w := range v.group.Watches() {
+		path, err := v.export(w, now)
+		if err != nil {
+			return paths, err
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// render draws the selected stopwatch, the list of stopwatches when there
+// are several, and the selected stopwatch's laps, fitting the digits into
+// what rows the rest leaves.
+func (v *stopwatchView) render(disp *display, showColon bool, rows int) string {
+	var b strings.Builder
+	if v.naming != nil {
+		fmt.Fprintf(&b, "  \033[1mNew stopwatch\033[0m   ENTER: add  |  ESC: cancel\033[K\n\n")
+		fmt.Fprintf(&b, "  Name: \033[7m%s \033[0m\033[K\n", *v.naming)
+		if v.err != nil {
+			fmt.Fprintf(&b, "\n  \033[1;31m⚠ %v\033[0m\033[K\n", v.err)
+		}
+		return b.String()
+	}
+	w, ok := v.current()
+	if !ok {
+		b.WriteString("  \033[90mNo stopwatches. Press n to add one.\033[0m\033[K\n")
+		return b.String()
+	}
+	var rest strings.Builder
+	fmt.Fprintf(&rest, "\n\n  \033[1m%s\033[0m  %s\033[K\n", w.Name, runStatus(w.Stopwatch))
+
+	if watches := v.group.Watches(); len(watches) > 1 {
+		rest.WriteString(v.renderWatches(watches))
+	}
+	if laps := w.Laps(); len(laps) > 0 {
+		rest.WriteString(renderLaps(laps))
+	}
+	switch {
+	case v.err != nil:
+		fmt.Fprintf(&rest, "\n  \033[1;31m⚠ %v\033[0m\033[K\n", v.err)
+	case v.saved != "":
+		fmt.Fprintf(&rest, "\n  \033[90mLaps saved to %s\033[0m\033[K\n", v.saved)
+	}
+	elapsed := w.Elapsed()
+	b.WriteString(disp.digits(func(f *clock.Font) string {
+		return f.RenderDurationCentis(elapsed, showColon)
+	}, clock.DurationText(elapsed), rows-lineCount(rest.String())+1))
+	b.WriteString(rest.String())
+	return b.String()
+}
+
+// runStatus describes whether sw is running.
+func runStatus(sw *stopwatch.Stopwatch) string {
+	if sw.IsRunning() {
+		return "\033[32m● Running\033[0m"
+	}
+	return "\033[31m⏸ Stopped\033[0m"
+}
+
+// renderWatches lists the stopwatches with their times, marking the
+// selected one.
+func (v *stopwatchView) renderWatches(watches []stopwatch.Named) string {
+	var b strings.Builder
+	mode := "run together"
+	if v.group.Exclusive {
+		mode = "one at a time"
+	}
+	fmt.Fprintf(&b, "\n  \033[1m  %-24s  %-14s\033[0m  \033[90m%s\033[0m\033[K\n", "NAME", "TIME", mode)
+	for i, w := range watches {
+		cursor, style := " ", ""
+		if i == v.selected {
+			cursor, style = "▶", "\033[7m"
+		}
+		fmt.Fprintf(&b, "  %s%s%-24s  %-14s\033[0m  %s\033[K\n", cursor, style,
+			truncate(w.Name, 24), stopwatch.FormatDuration(w.Elapsed()), runStatus(w.Stopwatch))
+	}
+	return b.String()
+}
+
+// renderLaps draws the most recent laps, newest first, with the fastest in
+// green and the slowest in red.
+func renderLaps(laps []stopwatch.Lap) string {
+	var b strings.Builder
+	fastest, slowest := stopwatch.Extremes(laps)
+	fmt.Fprintf(&b, "\n  \033[1m%4s  %-14s  %-14s\033[0m\033[K\n", "LAP", "TIME", "SPLIT")
+	for i := len(laps) - 1; i >= 0 && i >= len(laps)-lapRows; i-- {
+		color, mark := "", ""
+		switch i {
+		case fastest:
+			color, mark = "\033[32m", "  fastest"
+		case slowest:
+			color, mark = "\033[31m", "  slowest"
+		}
+		l := laps[i]
+		fmt.Fprintf(&b, "  %s%4d  %-14s  %-14s%s\033[0m\033[K\n", color, l.Number,
+			stopwatch.FormatDuration(l.Time), stopwatch.FormatDuration(l.Split), mark)
+	}
+	if hidden := len(laps) - lapRows; hidden > 0 {
+		fmt.Fprintf(&b, "  \033[90m… %d earlier laps\033[0m\033[K\n", hidden)
+	}
+	return b.String()
+}
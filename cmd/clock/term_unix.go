@@ -0,0 +1,40 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// winsize is the terminal size reported by TIOCGWINSZ.
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// termSize returns the terminal's width and height, or zeros if standard
+// output is not a terminal.
+func termSize() (width, height int) {
+	var ws winsize
+	if _, _, err := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(),
+		uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws))); err != 0 {
+		return 0, 0
+	}
+	return int(ws.cols), int(ws.rows)
+}
+
+// watchResize signals ch each time the terminal is resized.
+func watchResize(ch chan<- struct{}) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+	go func() {
+		for range sig {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}()
+}
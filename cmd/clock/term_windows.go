@@ -0,0 +1,56 @@
+//go:build windows
+
+package main
+
+import (
+	"time"
+	"unsafe"
+)
+
+var procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+
+const stdOutputHandle = ^uintptr(0) - 11 + 1 // STD_OUTPUT_HANDLE = -11
+
+type coord struct{ x, y int16 }
+
+type smallRect struct{ left, top, right, bottom int16 }
+
+type consoleScreenBufferInfo struct {
+	size              coord
+	cursorPosition    coord
+	attributes        uint16
+	window            smallRect
+	maximumWindowSize coord
+}
+
+// termSize returns the console window's width and height, or zeros if
+// standard output is not a console.
+func termSize() (width, height int) {
+	handle, _, _ := procGetStdHandle.Call(stdOutputHandle)
+	var info consoleScreenBufferInfo
+	if ret, _, _ := procGetConsoleScreenBufferInfo.Call(handle, uintptr(unsafe.Pointer(&info))); ret == 0 {
+		return 0, 0
+	}
+	w := info.window
+	return int(w.right-w.left) + 1, int(w.bottom-w.top) + 1
+}
+
+// resizePoll is how often the console size is checked, as Windows has no
+// signal for it.
+const resizePoll = 250 * time.Millisecond
+
+// watchResize signals ch each time the console window is resized.
+func watchResize(ch chan<- struct{}) {
+	go func() {
+		width, height := termSize()
+		for range time.Tick(resizePoll) {
+			if w, h := termSize(); w != width || h != height {
+				width, height = w, h
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/audio"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/clock"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/config"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/dashboard"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/timer"
+)
+
+// defaultTimer is what the timer is set to at launch.
+const defaultTimer = 5 * time.Minute
+
+// maxEntryDigits is how many digits can be typed to set the timer: HHMMSS.
+const maxEntryDigits = 6
+
+// timerSound is played when a countdown finishes.
+var timerSound = audio.Sequence{
+	{Sound: "beep-hour.wav"}, {Gap: 200 * time.Millisecond},
+	{Sound: "beep-hour.wav"}, {Gap: 200 * time.Millisecond},
+	{Sound: "beep-hour.wav"},
+}
+
+// timerView is the Timer mode: a countdown that can run Pomodoro cycles.
+type timerView struct {
+	timer    *timer.Timer
+	entry    string          // digits typed to set the timer
+	pomodoro *timer.Pomodoro // nil unless in a Pomodoro cycle
+	settings config.Pomodoro
+	tally    *timer.Tally
+	err      error // from saving the tally
+}
+
+// newTimerView creates the Timer mode with Pomodoro settings from cfg,
+// counting down by clk.
+func newTimerView(settings config.Pomodoro, tally *timer.Tally, clk chrono.Clock) *timerView {
+	return &timerView{timer: timer.NewWithClock(defaultTimer, clk), settings: settings, tally: tally}
+}
+
+// key handles a key press in Timer mode, reporting whether it was used.
+// Digits set the timer like a microwave oven: typing 1, 3, 0 sets 1:30.
+func (v *timerView) key(key byte) bool {
+	switch {
+	case key >= '0' && key <= '9':
+		if !v.timer.IsRunning() && len(v.entry) < maxEntryDigits && (v.entry != "" || key != '0') {
+			v.entry += string(rune(key))
+			v.pomodoro = nil
+			v.timer.Set(parseEntry(v.entry))
+		}
+	case key == 0x7f || key == 0x08: // backspace
+		if !v.timer.IsRunning() && v.entry != "" {
+			v.entry = v.entry[:len(v.entry)-1]
+			v.timer.Set(parseEntry(v.entry))
+		}
+	case key == ' ':
+		v.entry = ""
+		v.timer.Toggle()
+	case key == 'm' || key == 'M':
+		v.timer.Add(time.Minute)
+	case key == 'r' || key == 'R':
+		v.entry = ""
+		v.timer.Reset()
+	case key == 'p' || key == 'P':
+		v.entry = ""
+		if v.pomodoro != nil {
+			v.pomodoro = nil
+			v.timer.Set(defaultTimer)
+			break
+		}
+		v.pomodoro = &timer.Pomodoro{
+			Work:       time.Duration(v.settings.Work),
+			ShortBreak: time.Duration(v.settings.ShortBreak),
+			LongBreak:  time.Duration(v.settings.LongBreak),
+			LongEvery:  v.settings.LongEvery,
+		}
+		v.timer.Set(v.pomodoro.Length())
+	default:
+		return false
+	}
+	return true
+}
+
+// parseEntry reads typed digits as [[H]H][M]M[S]S, right-aligned.
+func parseEntry(entry string) time.Duration {
+	digits := fmt.Sprintf("%06s", entry)
+	h, _ := strconv.Atoi(digits[0:2])
+	m, _ := strconv.Atoi(digits[2:4])
+	s, _ := strconv.Atoi(digits[4:6])
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+}
+
+// expired checks whether the countdown has just finished. If so it moves a
+// Pomodoro cycle on, counting finished work sessions, and returns what to
+// announce.
+func (v *timerView) expired(now time.Time) (title, message string, ok bool) {
+	if !v.timer.Expired() {
+		return "", "", false
+	}
+	if v.pomodoro == nil {
+		return "Timer", fmt.Sprintf("%s is up", formatLength(v.timer.Length())), true
+	}
+	finished := v.pomodoro.Phase()
+	if finished == timer.Work {
+		v.err = v.tally.Add(now)
+	}
+	next := v.pomodoro.Advance()
+	v.timer.Set(v.pomodoro.Length())
+	return "Pomodoro", fmt.Sprintf("%s done. Next: %s, %s", finished, strings.ToLower(next.String()), formatLength(v.pomodoro.Length())), true
+}
+
+// formatLength describes a duration in words, e.g. "1 h 30 min" or "45 s".
+func formatLength(d time.Duration) string {
+	d = d.Round(time.Second)
+	var parts []string
+	if h := int(d.Hours()); h > 0 {
+		parts = append(parts, fmt.Sprintf("%d h", h))
+	}
+	if m := int(d.Minutes()) % 60; m > 0 {
+		parts = append(parts, fmt.Sprintf("%d min", m))
+	}
+	if s := int(d.Seconds()) % 60; s > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%d s", s))
+	}
+	return strings.Join(parts, " ")
+}
+
+// render draws the countdown, fitting the digits into what rows the rest
+// leaves.
+func (v *timerView) render(now time.Time, disp *display, showColon bool, rows int) string {
+	var b strings.Builder
+	// Count whole seconds up, so that 00:00 only shows once time is up.
+	left := (v.timer.Remaining() + time.Second - 1).Truncate(time.Second)
+	if !v.timer.IsRunning() {
+		showColon = true
+	}
+	if v.pomodoro != nil {
+		fmt.Fprintf(&b, "  \033[1m🍅 %s\033[0m  (round %d of %d)\033[K\n\n", v.pomodoro.Phase(), v.pomodoro.Round(), v.settings.LongEvery)
+	}
+	var rest strings.Builder
+	rest.WriteString("\n\n  ")
+
+	status := "\033[31m⏸ Paused\033[0m"
+	switch {
+	case v.timer.IsRunning():
+		status = "\033[32m● Running\033[0m"
+	case v.timer.Remaining() == v.timer.Length():
+		status = "\033[90m■ Ready\033[0m"
+	}
+	if length := v.timer.Length(); length > 0 {
+		done := float64(length-v.timer.Remaining()) / float64(length) * 100
+		rest.WriteString(dashboard.ProgressBar(done, playbackBarWidth) + "  ")
+	}
+	fmt.Fprintf(&rest, "%s\033[K\n", status)
+	if v.pomodoro != nil || v.tally.Today(now) > 0 {
+		fmt.Fprintf(&rest, "\n  🍅 Today: %d\033[K\n", v.tally.Today(now))
+	}
+	if v.err != nil {
+		fmt.Fprintf(&rest, "\n  \033[1;31m⚠ %v\033[0m\033[K\n", v.err)
+	}
+	rows -= lineCount(b.String()) - 1 + lineCount(rest.String()) - 1
+	b.WriteString(disp.digits(func(f *clock.Font) string {
+		return f.RenderDuration(left, showColon)
+	}, clock.DurationText(left), rows))
+	b.WriteString(rest.String())
+	return b.String()
+}
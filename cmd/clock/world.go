@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/clock"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/worldclock"
+)
+
+// worldView is the World Clock mode: the local time in big digits, with a
+// row for each of the other zones.
+type worldView struct {
+	list     *worldclock.List
+	selected int
+	form     *zoneForm // nil unless adding a zone
+	err      error     // from the last save
+}
+
+// save writes the zones, keeping any error for display.
+func (v *worldView) save() {
+	v.err = v.list.Save()
+}
+
+// key handles a key press in World Clock mode, reporting whether it was
+// used.
+func (v *worldView) key(key byte) bool {
+	if v.form != nil {
+		switch v.form.key(key) {
+		case formCancel:
+			v.form = nil
+		case formDone:
+			if _, err := v.list.Add(v.form.fields[zoneFieldName], v.form.fields[zoneFieldLabel]); err != nil {
+				v.form.err = err
+				return true
+			}
+			v.selected = len(v.list.Zones) - 1
+			v.form = nil
+			v.save()
+		}
+		return true
+	}
+
+	switch key {
+	case keyUp:
+		v.selected = max(0, v.selected-1)
+	case keyDown:
+		v.selected = min(len(v.list.Zones)-1, v.selected+1)
+	case 'n', 'N':
+		v.form = &zoneForm{}
+	case 'x', 'X':
+		if v.selected < len(v.list.Zones) {
+			v.list.Remove(v.selected)
+			v.selected = max(0, min(v.selected, len(v.list.Zones)-1))
+			v.save()
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// render draws the local time in big digits, fitted into what rows the
+// list of zones leaves, and the list, or the form.
+func (v *worldView) render(now time.Time, disp *display, showColon bool, rows int) string {
+	var b strings.Builder
+	if v.err != nil {
+		fmt.Fprintf(&b, "  \033[1;31m⚠ %v\033[0m\033[K\n\n", v.err)
+	}
+	if v.form != nil {
+		b.WriteString(v.form.render())
+		return b.String()
+	}
+
+	var rest strings.Builder
+	name, offset := now.Zone()
+	zone := worldclock.OffsetText(time.Duration(offset) * time.Second)
+	// Zones without an abbreviation are named by their offset, as "+03".
+	if name != zone && !strings.HasPrefix(name, "+") && !strings.HasPrefix(name, "-") {
+		zone = name + " " + zone
+	}
+	fmt.Fprintf(&rest, "\n\n  \033[1mLocal\033[0m  %s  %s\033[K\n\n", now.Format("Monday, 02 January"), zone)
+	rest.WriteString(v.renderZones(now, disp.format))
+
+	rows -= lineCount(b.String()) - 1 + lineCount(rest.String()) - 1
+	b.WriteString(disp.digits(func(f *clock.Font) string {
+		return f.RenderTime(now, showColon, disp.format)
+	}, clock.TimeText(now, disp.format), rows))
+	b.WriteString(rest.String())
+	return b.String()
+}
+
+// renderZones lists the zones with their times, marking those within
+// working hours and the selected one.
+func (v *worldView) renderZones(now time.Time, format clock.Format) string {
+	if len(v.list.Zones) == 0 {
+		return "  \033[90mNo other time zones. Press n to add one.\033[0m\033[K\n"
+	}
+	format.Seconds = false
+	h := v.list.Hours
+	var b strings.Builder
+	fmt.Fprintf(&b, "  \033[1m  %-16s  %-8s  %-6s  %-9s  %-24s\033[0m  \033[90mworking hours %s–%s, %s\033[0m\033[K\n",
+		"CITY", "TIME", "DAY", "UTC", "ZONE", h.From, h.To, h.Days)
+	for i, z := range v.list.Zones {
+		at := z.At(now)
+		cursor, style, status := " ", "\033[90m", "\033[90m○ off hours"
+		if h.Contains(at.Time) {
+			style, status = "\033[32m", "\033[32m● working"
+		}
+		if i == v.selected {
+			cursor, style = "▶", style+"\033[7m"
+		}
+		day := strings.TrimSpace(at.Format("Mon") + " " + worldclock.DayOffsetText(at.DayOffset))
+		fmt.Fprintf(&b, "  %s%s%-16s  %-8s  %-6s  %-9s  %-24s\033[0m  %s\033[0m\033[K\n",
+			cursor, style, truncate(z.Label, 16), clock.TimeText(at.Time, format), day,
+			worldclock.OffsetText(at.Offset), truncate(z.Name, 24), status)
+	}
+	return b.String()
+}
+
+// Fields of the zone form.
+const (
+	zoneFieldName = iota
+	zoneFieldLabel
+	zoneFieldCount
+)
+
+var zoneFieldNames = [zoneFieldCount]string{"Time zone", "Label"}
+
+var zoneFieldHints = [zoneFieldCount]string{
+	"an IANA name such as Europe/London or America/New_York",
+	"empty to name it after the city",
+}
+
+// zoneForm asks for a zone to add.
+type zoneForm struct {
+	fields [zoneFieldCount]string
+	focus  int
+	err    error
+}
+
+// key edits the focused field. TAB and ENTER move to the next field, ENTER
+// on the last adds the zone and ESC cancels.
+func (f *zoneForm) key(key byte) int {
+	switch {
+	case key == 0x1b:
+		return formCancel
+	case key == '\t':
+		f.focus = (f.focus + 1) % zoneFieldCount
+	case key == '\r' || key == '\n':
+		if f.focus == zoneFieldCount-1 {
+			return formDone
+		}
+		f.focus++
+	case key == 0x7f || key == 0x08: // backspace
+		if r := []rune(f.fields[f.focus]); len(r) > 0 {
+			f.fields[f.focus] = string(r[:len(r)-1])
+		}
+	case key >= 0x20 && key < 0x7f:
+		f.fields[f.focus] += string(rune(key))
+	}
+	f.err = nil
+	return formEditing
+}
+
+// render draws the form.
+func (f *zoneForm) render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "  \033[1mAdd a time zone\033[0m   TAB/ENTER: next field  |  ESC: cancel\033[K\n\n")
+	for i, name := range zoneFieldNames {
+		value := f.fields[i]
+		if i == f.focus {
+			value = "\033[7m" + value + " \033[0m"
+		}
+		fmt.Fprintf(&b, "  %-10s %s\033[K\n", name+":", value)
+		if i == f.focus {
+			fmt.Fprintf(&b, "  %-10s \033[90m%s\033[0m\033[K\n", "", zoneFieldHints[i])
+		}
+	}
+	if f.focus == zoneFieldCount-1 {
+		b.WriteString("\n  \033[90mENTER adds the zone\033[0m\033[K\n")
+	}
+	if f.err != nil {
+		fmt.Fprintf(&b, "\n  \033[1;31m⚠ %v\033[0m\033[K\n", f.err)
+	}
+	return b.String()
+}
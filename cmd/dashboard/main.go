@@ -6,6 +6,7 @@ import (
 	"os/signal"
 	"time"
 
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
 	"github.com/dadyutenga/upgraded-octo-parakeet/internal/dashboard"
 )
 
@@ -35,7 +36,7 @@ func main() {
 }
 
 func render() {
-	info := dashboard.Collect(200 * time.Millisecond)
+	info := dashboard.Collect(chrono.System, 200*time.Millisecond)
 	fmt.Print("\033[H") // move cursor to top
 	fmt.Print(dashboard.Render(info))
 }
@@ -1,3 +1,5 @@
 module github.com/dadyutenga/upgraded-octo-parakeet
 
 go 1.24.12
+
+require github.com/hajimehoshi/go-mp3 v0.3.4
@@ -0,0 +1,289 @@
+// Package alarm keeps the user's alarms: when they ring, how they repeat
+// and how they are snoozed, saved to a JSON file.
+package alarm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/atomicfile"
+)
+
+// DefaultSnooze is how long an alarm is snoozed unless it says otherwise.
+const DefaultSnooze = 5 * time.Minute
+
+// Weekdays is a set of days of the week, with bit d set for time.Weekday d.
+// The empty set means an alarm rings once.
+type Weekdays uint8
+
+// Common sets of days.
+const (
+	Daily    Weekdays = 1<<7 - 1
+	Weekends Weekdays = 1<<time.Saturday | 1<<time.Sunday
+	Workdays Weekdays = Daily &^ Weekends
+)
+
+var dayNames = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Has reports whether d is in the set.
+func (w Weekdays) Has(d time.Weekday) bool {
+	return w&(1<<d) != 0
+}
+
+// String returns the set as ParseWeekdays accepts it, e.g. "weekdays" or
+// "mon,wed,fri".
+func (w Weekdays) String() string {
+	switch w {
+	case 0:
+		return "once"
+	case Daily:
+		return "daily"
+	case Workdays:
+		return "weekdays"
+	case Weekends:
+		return "weekends"
+	}
+	var days []string
+	// Start the week on Monday.
+	for i := 1; i <= 7; i++ {
+		if d := time.Weekday(i % 7); w.Has(d) {
+			days = append(days, dayNames[d])
+		}
+	}
+	return strings.Join(days, ",")
+}
+
+// MarshalText stores the set as its String.
+func (w Weekdays) MarshalText() ([]byte, error) {
+	return []byte(w.String()), nil
+}
+
+// UnmarshalText reads a set written as ParseWeekdays accepts.
+func (w *Weekdays) UnmarshalText(text []byte) error {
+	v, err := ParseWeekdays(string(text))
+	if err != nil {
+		return err
+	}
+	*w = v
+	return nil
+}
+
+// ParseWeekdays parses "once", "daily", "weekdays", "weekends" or a comma
+// separated list of days and ranges such as "mon-thu,sat". An empty string
+// means once.
+func ParseWeekdays(s string) (Weekdays, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "once":
+		return 0, nil
+	case "daily", "every day":
+		return Daily, nil
+	case "weekdays":
+		return Workdays, nil
+	case "weekends":
+		return Weekends, nil
+	}
+	var w Weekdays
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, err := parseDay(from)
+		if err != nil {
+			return 0, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseDay(to); err != nil {
+				return 0, err
+			}
+		}
+		// Ranges may wrap around the end of the week, as in "fri-mon".
+		for d := first; ; d = (d + 1) % 7 {
+			w |= 1 << d
+			if d == last {
+				break
+			}
+		}
+	}
+	return w, nil
+}
+
+// parseDay parses a day name, of which the first three letters are enough.
+func parseDay(s string) (time.Weekday, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 3 {
+		for d, name := range dayNames {
+			if strings.HasPrefix(s, name) {
+				return time.Weekday(d), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown day %q", s)
+}
+
+// Alarm is one alarm.
+type Alarm struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	// Time is the time of day it rings, "HH:MM".
+	Time string   `json:"time"`
+	Days Weekdays `json:"days"`
+	// Sound is the file to play; empty means a beep.
+	Sound         string `json:"sound"`
+	SnoozeMinutes int    `json:"snooze_minutes"`
+	Enabled       bool   `json:"enabled"`
+
+	// SnoozedUntil is when a snoozed alarm rings again.
+	SnoozedUntil time.Time `json:"snoozed_until,omitempty"`
+	// LastRung stops an alarm ringing twice in the same minute.
+	LastRung time.Time `json:"last_rung,omitempty"`
+}
+
+// ParseTime checks a time of day written "HH:MM" and returns its hour and
+// minute.
+func ParseTime(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// SnoozeFor returns how long the alarm is snoozed for.
+func (a *Alarm) SnoozeFor() time.Duration {
+	if a.SnoozeMinutes <= 0 {
+		return DefaultSnooze
+	}
+	return time.Duration(a.SnoozeMinutes) * time.Minute
+}
+
+// Next returns when the alarm will next ring after now, or the zero time if
+// it is off.
+func (a *Alarm) Next(now time.Time) time.Time {
+	if !a.Enabled {
+		return time.Time{}
+	}
+	if !a.SnoozedUntil.IsZero() {
+		return a.SnoozedUntil
+	}
+	hour, minute, err := ParseTime(a.Time)
+	if err != nil {
+		return time.Time{}
+	}
+	for i := 0; i <= 7; i++ {
+		day := now.AddDate(0, 0, i)
+		at := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location())
+		if at.After(now) && (a.Days == 0 || a.Days.Has(at.Weekday())) {
+			return at
+		}
+	}
+	return time.Time{}
+}
+
+// Due reports whether the alarm should start ringing at now.
+func (a *Alarm) Due(now time.Time) bool {
+	if !a.Enabled {
+		return false
+	}
+	if !a.SnoozedUntil.IsZero() {
+		return !now.Before(a.SnoozedUntil)
+	}
+	hour, minute, err := ParseTime(a.Time)
+	if err != nil || now.Hour() != hour || now.Minute() != minute {
+		return false
+	}
+	if a.Days != 0 && !a.Days.Has(now.Weekday()) {
+		return false
+	}
+	return !a.LastRung.Truncate(time.Minute).Equal(now.Truncate(time.Minute))
+}
+
+// Ring records that a has started ringing at now. An alarm without
+// recurrence turns itself off.
+func (a *Alarm) Ring(now time.Time) {
+	a.LastRung = now
+	a.SnoozedUntil = time.Time{}
+	if a.Days == 0 {
+		a.Enabled = false
+	}
+}
+
+// Snooze makes a ringing alarm ring again after its snooze duration.
+func (a *Alarm) Snooze(now time.Time) {
+	a.Enabled = true
+	a.SnoozedUntil = now.Add(a.SnoozeFor())
+}
+
+// List is the user's alarms, stored in a file.
+type List struct {
+	Alarms []*Alarm `json:"alarms"`
+	path   string
+}
+
+// Load reads the alarms stored at path. A missing file yields no alarms.
+func Load(path string) (*List, error) {
+	l := &List{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read alarms: %w", err)
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("parse alarms %s: %w", path, err)
+	}
+	for _, a := range l.Alarms {
+		if _, _, err := ParseTime(a.Time); err != nil {
+			return nil, fmt.Errorf("alarms %s: alarm %d: %w", path, a.ID, err)
+		}
+	}
+	return l, nil
+}
+
+// Save writes the alarms back to their file.
+func (l *List) Save() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode alarms: %w", err)
+	}
+	if err := atomicfile.Write(l.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("save alarms: %w", err)
+	}
+	return nil
+}
+
+// Add adds an alarm, giving it a new ID.
+func (l *List) Add(a Alarm) *Alarm {
+	a.ID = 0
+	for _, other := range l.Alarms {
+		a.ID = max(a.ID, other.ID)
+	}
+	a.ID++
+	l.Alarms = append(l.Alarms, &a)
+	return &a
+}
+
+// Delete removes the alarm with the given ID.
+func (l *List) Delete(id int) {
+	for i, a := range l.Alarms {
+		if a.ID == id {
+			l.Alarms = append(l.Alarms[:i], l.Alarms[i+1:]...)
+			return
+		}
+	}
+}
+
+// Due returns the first alarm that should start ringing at now.
+func (l *List) Due(now time.Time) (*Alarm, bool) {
+	for _, a := range l.Alarms {
+		if a.Due(now) {
+			return a, true
+		}
+	}
+	return nil, false
+}
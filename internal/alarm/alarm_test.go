@@ -0,0 +1,145 @@
+package alarm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWeekdays(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Weekdays
+		str  string
+	}{
+		{"", 0, "once"},
+		{"daily", Daily, "daily"},
+		{"Weekdays", Workdays, "weekdays"},
+		{"mon-fri", Workdays, "weekdays"},
+		{"sat,sun", Weekends, "weekends"},
+		{"monday, wed, friday", 1<<time.Monday | 1<<time.Wednesday | 1<<time.Friday, "mon,wed,fri"},
+		{"fri-mon", 1<<time.Friday | Weekends | 1<<time.Monday, "mon,fri,sat,sun"},
+	}
+	for _, tt := range tests {
+		got, err := ParseWeekdays(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseWeekdays(%q) = %b, %v; want %b", tt.in, got, err, tt.want)
+		}
+		if got.String() != tt.str {
+			t.Errorf("String() = %q, want %q", got.String(), tt.str)
+		}
+	}
+	for _, bad := range []string{"someday", "mo", "mon-", "mon,,tue"} {
+		if _, err := ParseWeekdays(bad); err == nil {
+			t.Errorf("ParseWeekdays(%q) succeeded", bad)
+		}
+	}
+}
+
+// at returns 2024-06-03 (a Monday) plus days at the time of day.
+func at(days int, clock string) time.Time {
+	h, m, _ := ParseTime(clock)
+	return time.Date(2024, 6, 3+days, h, m, 0, 0, time.Local)
+}
+
+func TestAlarm_Due(t *testing.T) {
+	a := &Alarm{Time: "07:30", Days: Workdays, Enabled: true}
+	if !a.Due(at(0, "07:30").Add(20 * time.Second)) {
+		t.Error("expected the alarm to be due on Monday at 07:30")
+	}
+	if a.Due(at(0, "07:31")) || a.Due(at(5, "07:30")) {
+		t.Error("expected the alarm to be due only at its time on weekdays")
+	}
+
+	a.Ring(at(0, "07:30"))
+	if a.Due(at(0, "07:30").Add(30*time.Second)) || !a.Enabled {
+		t.Error("expected a repeating alarm to ring once a minute and stay on")
+	}
+	if !a.Due(at(1, "07:30")) {
+		t.Error("expected the alarm to ring again on Tuesday")
+	}
+
+	a.Snooze(at(1, "07:30"))
+	if a.Due(at(1, "07:34")) || !a.Due(at(1, "07:35")) {
+		t.Error("expected the alarm to ring again after 5 minutes")
+	}
+	if next := a.Next(at(1, "07:31")); !next.Equal(at(1, "07:35")) {
+		t.Errorf("Next = %v, want the end of the snooze", next)
+	}
+	a.Ring(at(1, "07:35"))
+	if next := a.Next(at(1, "07:36")); !next.Equal(at(2, "07:30")) {
+		t.Errorf("Next = %v, want Wednesday 07:30", next)
+	}
+	if next := a.Next(at(4, "08:00")); !next.Equal(at(7, "07:30")) {
+		t.Errorf("Next = %v, want next Monday 07:30", next)
+	}
+
+	once := &Alarm{Time: "06:00", Enabled: true, SnoozeMinutes: 10}
+	once.Ring(at(0, "06:00"))
+	if once.Enabled || !once.Next(at(0, "06:01")).IsZero() {
+		t.Error("expected an alarm without recurrence to turn off after ringing")
+	}
+	once.Snooze(at(0, "06:00"))
+	if !once.Due(at(0, "06:10")) {
+		t.Error("expected a snoozed one-off alarm to ring again")
+	}
+}
+
+func TestList_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "my-clock", "alarms.json")
+	l, err := Load(path)
+	if err != nil || len(l.Alarms) != 0 {
+		t.Fatalf("Load of a missing file = %v, %v", l, err)
+	}
+	first := l.Add(Alarm{Label: "Wake up", Time: "06:45", Days: Workdays, Enabled: true})
+	second := l.Add(Alarm{Label: "Gym", Time: "18:00", Days: 1 << time.Tuesday, Sound: "bell.wav", SnoozeMinutes: 10})
+	if first.ID == second.ID {
+		t.Error("expected distinct IDs")
+	}
+	second.Snooze(at(0, "18:00"))
+	if err := l.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"days": "weekdays"`) {
+		t.Errorf("expected days to be stored readably, got %s", data)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded.Alarms) != 2 {
+		t.Fatalf("expected 2 alarms, got %d", len(loaded.Alarms))
+	}
+	got := loaded.Alarms[1]
+	if got.Label != "Gym" || got.Days != 1<<time.Tuesday || got.Sound != "bell.wav" || !got.SnoozedUntil.Equal(second.SnoozedUntil) {
+		t.Errorf("unexpected alarm after reload: %+v", got)
+	}
+
+	loaded.Delete(first.ID)
+	if len(loaded.Alarms) != 1 || loaded.Alarms[0].ID != second.ID {
+		t.Errorf("Delete removed the wrong alarm: %+v", loaded.Alarms)
+	}
+	if a := loaded.Add(Alarm{Time: "09:00"}); a.ID <= second.ID {
+		t.Errorf("expected a new ID, got %d", a.ID)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`{"alarms": [{"time": "25:00"}]}`,
+		`{"alarms": [{"time": "07:00", "days": "someday"}]}`,
+		`not json`,
+	} {
+		path := filepath.Join(t.TempDir(), "alarms.json")
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package alert shows alerts without sound: a flashing full-screen banner,
+// the terminal bell, terminal notifications (OSC 9 and OSC 777) and tmux
+// messages.
+package alert
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// Bell rings the terminal bell.
+const Bell = "\a"
+
+// OSC9 returns the escape sequence for an iTerm2/ConEmu/Windows Terminal
+// style notification.
+func OSC9(message string) string {
+	return "\033]9;" + sanitize(message) + "\a"
+}
+
+// OSC777 returns the escape sequence for an rxvt/foot/Ghostty style
+// notification with a title and body.
+func OSC777(title, body string) string {
+	return "\033]777;notify;" + sanitize(title) + ";" + sanitize(body) + "\a"
+}
+
+// tmuxPassthrough wraps seq so that tmux forwards it to the outer terminal.
+func tmuxPassthrough(seq string) string {
+	return "\033Ptmux;" + strings.ReplaceAll(seq, "\033", "\033\033") + "\033\\"
+}
+
+// sanitize removes control characters, and the ';' that separates OSC
+// fields, so that text cannot end or corrupt an escape sequence.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == ';' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// Notifier sends alerts to the terminal.
+type Notifier struct {
+	out  io.Writer
+	tmux bool // running inside tmux
+	run  func(name string, args ...string) error
+}
+
+// NewNotifier creates a Notifier writing to out, which should be the
+// terminal. It detects tmux from $TMUX.
+func NewNotifier(out io.Writer) *Notifier {
+	return &Notifier{
+		out:  out,
+		tmux: os.Getenv("TMUX") != "",
+		run: func(name string, args ...string) error {
+			return exec.Command(name, args...).Run()
+		},
+	}
+}
+
+// Notify rings the bell, sends terminal notifications and, inside tmux,
+// shows a tmux message. Terminals that do not understand a notification
+// ignore it.
+func (n *Notifier) Notify(title, body string) error {
+	seqs := OSC9(title+": "+body) + OSC777(title, body)
+	if n.tmux {
+		seqs = tmuxPassthrough(seqs)
+	}
+	if _, err := io.WriteString(n.out, Bell+seqs); err != nil {
+		return fmt.Errorf("write notification: %w", err)
+	}
+	if n.tmux {
+		if err := n.run("tmux", "display-message", sanitize(title+": "+body)); err != nil {
+			return fmt.Errorf("tmux display-message: %w", err)
+		}
+	}
+	return nil
+}
+
+// Banner renders a full-screen banner of width by height cells with title
+// and message centred on it. Alternate calls with on true and false make it
+// flash.
+func Banner(title, message string, width, height int, on bool) string {
+	return Screen([]string{
+		strings.ToUpper(title),
+		"",
+		message,
+		"",
+		"press any key to dismiss",
+	}, width, height, on)
+}
+
+// Screen renders lines centred on a full screen of width by height cells,
+// flashing like Banner.
+func Screen(lines []string, width, height int, on bool) string {
+	colors := "\033[1;97;41m" // bold white on red
+	if !on {
+		colors = "\033[1;31;40m" // bold red on black
+	}
+	blank := strings.Repeat(" ", width)
+	top := max(0, (height-len(lines))/2)
+
+	var b strings.Builder
+	b.WriteString("\033[H")
+	for row := 0; row < height; row++ {
+		line := blank
+		if i := row - top; i >= 0 && i < len(lines) {
+			line = center(lines[i], width)
+		}
+		b.WriteString(colors + line + "\033[0m")
+		if row < height-1 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// center pads s with spaces to width, keeping it in the middle, and cuts it
+// if it is too long.
+func center(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return string([]rune(s)[:width])
+	}
+	left := (width - n) / 2
+	return strings.Repeat(" ", left) + s + strings.Repeat(" ", width-n-left)
+}
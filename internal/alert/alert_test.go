@@ -0,0 +1,86 @@
+package alert
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestOSC(t *testing.T) {
+	if got := OSC9("Dhuhr"); got != "\033]9;Dhuhr\a" {
+		t.Errorf("OSC9 = %q", got)
+	}
+	if got := OSC777("Azan", "Time for Dhuhr"); got != "\033]777;notify;Azan;Time for Dhuhr\a" {
+		t.Errorf("OSC777 = %q", got)
+	}
+	if got := OSC9("a\a;b\033]"); strings.ContainsAny(got[4:len(got)-1], "\a\033;") {
+		t.Errorf("expected control characters to be removed, got %q", got)
+	}
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	var out bytes.Buffer
+	n := &Notifier{out: &out, run: func(string, ...string) error {
+		t.Error("tmux should not be run outside tmux")
+		return nil
+	}}
+	if err := n.Notify("Azan", "Time for Asr"); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	want := Bell + OSC9("Azan: Time for Asr") + OSC777("Azan", "Time for Asr")
+	if out.String() != want {
+		t.Errorf("Notify wrote %q, want %q", out.String(), want)
+	}
+}
+
+func TestNotifier_Tmux(t *testing.T) {
+	var out bytes.Buffer
+	var ran []string
+	n := &Notifier{out: &out, tmux: true, run: func(name string, args ...string) error {
+		ran = append([]string{name}, args...)
+		return errors.New("no server running")
+	}}
+
+	err := n.Notify("Azan", "Time for Isha")
+	if err == nil || !strings.Contains(err.Error(), "tmux display-message") {
+		t.Errorf("expected the tmux error to be reported, got %v", err)
+	}
+	if strings.Join(ran, " ") != "tmux display-message Azan: Time for Isha" {
+		t.Errorf("unexpected tmux command %q", ran)
+	}
+	if !strings.HasPrefix(out.String(), Bell+"\033Ptmux;\033\033]9;") || !strings.HasSuffix(out.String(), "\033\\") {
+		t.Errorf("expected notifications wrapped for tmux passthrough, got %q", out.String())
+	}
+}
+
+func TestBanner(t *testing.T) {
+	on := Banner("Maghrib", "Time for Maghrib prayer", 40, 10, true)
+	off := Banner("Maghrib", "Time for Maghrib prayer", 40, 10, false)
+	if on == off {
+		t.Error("expected the banner to alternate colours")
+	}
+
+	lines := strings.Split(strings.TrimPrefix(on, "\033[H"), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("expected 10 rows, got %d", len(lines))
+	}
+	found := false
+	for _, l := range lines {
+		text := strings.TrimSuffix(l[strings.Index(l, "m")+1:], "\033[0m")
+		if n := utf8.RuneCountInString(text); n != 40 {
+			t.Errorf("expected every row to fill 40 columns, got %d in %q", n, text)
+		}
+		if strings.TrimSpace(text) == "MAGHRIB" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the title on the banner")
+	}
+
+	if got := center("too long for this", 3); got != "too" {
+		t.Errorf("expected long text to be cut, got %q", got)
+	}
+}
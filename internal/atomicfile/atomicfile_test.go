@@ -0,0 +1,41 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "my-clock", "alarms.json")
+	if err := Write(path, []byte("first\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := Write(path, []byte("second\n")); err != nil {
+		t.Fatalf("Write over an existing file failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "second\n" {
+		t.Errorf("read back %q, %v", data, err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected no temporary files left behind, got %d entries", len(entries))
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm()&0o600 != 0o600 {
+		t.Errorf("expected the file to be readable and writable, got %v", info.Mode())
+	}
+
+	// A directory in the way makes the rename fail; nothing is left behind.
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(blocked, []byte("data")); err == nil {
+		t.Error("expected writing over a directory to fail")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("expected the temporary file to be removed, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package atomicfile
+
+import "os"
+
+// syncDir flushes a directory's entries, such as a rename, to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
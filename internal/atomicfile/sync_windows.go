@@ -0,0 +1,7 @@
+package atomicfile
+
+// syncDir does nothing: Windows cannot open a directory to sync it, and
+// its renames are journaled by NTFS.
+func syncDir(dir string) error {
+	return nil
+}
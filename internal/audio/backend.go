@@ -0,0 +1,240 @@
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Sound formats understood by backends. FormatPCM is raw signed 16-bit
+// little-endian samples, decoded in process.
+const (
+	FormatMP3 = "mp3"
+	FormatWAV = "wav"
+	FormatPCM = "pcm"
+)
+
+// Placeholders in backend command templates. FilePlaceholder marks where
+// the command takes the path of the sound; a command without it is fed the
+// sound on standard input. Rate and channels describe PCM for FormatPCM
+// players. Volume (0-100) and gain (a linear factor, 1 for full scale) set
+// the starting level of players that decode the sound themselves.
+const (
+	FilePlaceholder     = "{file}"
+	RatePlaceholder     = "{rate}"
+	ChannelsPlaceholder = "{channels}"
+	VolumePlaceholder   = "{volume}"
+	GainPlaceholder     = "{gain}"
+)
+
+// Backend describes an external audio player.
+type Backend struct {
+	Name    string   // short name shown in diagnostics
+	Command []string // program and arguments; empty for a built-in player
+	Formats []string // formats the player can decode
+}
+
+// ParseBackend builds a Backend from a command template such as
+// "mpv --volume=60 -", "aplay -q {file}" or
+// "aplay -t raw -f S16_LE -c {channels} -r {rate}". Arguments are separated
+// by spaces. If formats is empty the player is assumed to handle MP3 and WAV.
+func ParseBackend(command string, formats []string) (Backend, error) {
+	argv := strings.Fields(command)
+	if len(argv) == 0 {
+		return Backend{}, errors.New("empty player command")
+	}
+	b := Backend{Name: filepath.Base(argv[0]), Command: argv}
+	if len(formats) == 0 {
+		formats = []string{FormatMP3, FormatWAV}
+	}
+	for _, f := range formats {
+		b.Formats = append(b.Formats, strings.ToLower(f))
+	}
+	return b, nil
+}
+
+// Supports reports whether the player can decode format.
+func (b Backend) Supports(format string) bool {
+	for _, f := range b.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Stdin reports whether the player reads the sound from standard input.
+func (b Backend) Stdin() bool {
+	for _, arg := range b.Command {
+		if strings.Contains(arg, FilePlaceholder) {
+			return false
+		}
+	}
+	return true
+}
+
+// args returns the command's arguments with the placeholders filled in. A
+// built-in player has none.
+func (b Backend) args(file string, s Stream, volume int) []string {
+	if len(b.Command) == 0 {
+		return nil
+	}
+	r := strings.NewReplacer(
+		FilePlaceholder, file,
+		RatePlaceholder, strconv.Itoa(s.SampleRate),
+		ChannelsPlaceholder, strconv.Itoa(s.Channels),
+		VolumePlaceholder, strconv.Itoa(clampVolume(volume)),
+		GainPlaceholder, strconv.FormatFloat(gainFor(volume), 'f', 3, 64),
+	)
+	args := make([]string, 0, len(b.Command)-1)
+	for _, arg := range b.Command[1:] {
+		args = append(args, r.Replace(arg))
+	}
+	return args
+}
+
+// DetectFormat identifies a sound from its contents, falling back to the
+// extension of name.
+func DetectFormat(data []byte, name string) string {
+	switch {
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WAVE")):
+		return FormatWAV
+	case bytes.HasPrefix(data, []byte("ID3")):
+		return FormatMP3
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return FormatMP3
+	}
+	return strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+}
+
+// Probe is the result of looking for one backend.
+type Probe struct {
+	Backend Backend
+	Path    string // resolved program; empty if not found
+	Err     error  // why the program was not found
+}
+
+// probeBackends looks up every backend's program on PATH.
+func probeBackends(backends []Backend) []Probe {
+	probes := make([]Probe, len(backends))
+	for i, b := range backends {
+		probes[i].Backend = b
+		if len(b.Command) == 0 {
+			probes[i].Path = "(built-in)"
+			continue
+		}
+		probes[i].Path, probes[i].Err = exec.LookPath(b.Command[0])
+	}
+	return probes
+}
+
+// Choice is the backend picked to play a sound.
+type Choice struct {
+	Backend Backend
+	Path    string // resolved program
+	Format  string // what the player is given; differs from the sound's if decoded in process
+	Reason  string
+}
+
+// chooseBackend picks the first available backend that can play a sound of
+// the given format, either directly or, for MP3 and WAV, as raw PCM decoded
+// in process. Raw PCM is preferred where a backend takes both, since volume and
+// fades can then be changed live. If no backend can, an MP3 falls back to
+// the first available backend that plays WAV, converting the sound in
+// process.
+func chooseBackend(probes []Probe, format string) (Choice, error) {
+	found := false
+	for _, p := range probes {
+		if p.Path == "" {
+			continue
+		}
+		found = true
+		switch {
+		case (format == FormatMP3 || format == FormatWAV) && p.Backend.Supports(FormatPCM):
+			return Choice{
+				Backend: p.Backend,
+				Path:    p.Path,
+				Format:  FormatPCM,
+				Reason:  "first available player; takes raw PCM decoded in process",
+			}, nil
+		case p.Backend.Supports(format):
+			return Choice{
+				Backend: p.Backend,
+				Path:    p.Path,
+				Format:  format,
+				Reason:  fmt.Sprintf("first available player; decodes %s itself", format),
+			}, nil
+		}
+	}
+	if !found {
+		return Choice{}, ErrNoPlayer
+	}
+	if format == FormatMP3 {
+		for _, p := range probes {
+			if p.Path != "" && p.Backend.Supports(FormatWAV) {
+				return Choice{
+					Backend: p.Backend,
+					Path:    p.Path,
+					Format:  FormatWAV,
+					Reason:  "no available player decodes mp3; converting to wav in process",
+				}, nil
+			}
+		}
+	}
+	return Choice{}, fmt.Errorf("no audio player can play %s", format)
+}
+
+// Diagnosis explains which backend would play a sound and why.
+type Diagnosis struct {
+	Sound  string
+	Format string
+	Probes []Probe
+	Choice Choice
+	Err    error // no backend can play the sound
+}
+
+// Diagnose reports which of backends would play name from fsys. A nil
+// backends means DefaultBackends.
+func Diagnose(backends []Backend, fsys fs.FS, name string) Diagnosis {
+	if backends == nil {
+		backends = DefaultBackends()
+	}
+	d := Diagnosis{Sound: name, Probes: probeBackends(backends)}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		d.Err = fmt.Errorf("read embedded file: %w", err)
+		return d
+	}
+	d.Format = DetectFormat(data, name)
+	d.Choice, d.Err = chooseBackend(d.Probes, d.Format)
+	return d
+}
+
+// Render formats the diagnosis for the terminal.
+func (d Diagnosis) Render() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Sound:   %s (%s)\n", d.Sound, d.Format))
+	b.WriteString("Players:\n")
+	for _, p := range d.Probes {
+		mark, where := "\033[32m✓\033[0m", p.Path
+		if p.Path == "" {
+			mark, where = "\033[31m✗\033[0m", "not found"
+		}
+		b.WriteString(fmt.Sprintf("  %s %-8s %-24s %s\n",
+			mark, p.Backend.Name, where, strings.Join(p.Backend.Formats, ", ")))
+	}
+
+	if d.Err != nil {
+		b.WriteString(fmt.Sprintf("\n\033[31m⚠ %s\033[0m\n", d.Err))
+		return b.String()
+	}
+	b.WriteString(fmt.Sprintf("\nUsing %s: %s\n", d.Choice.Backend.Name, d.Choice.Reason))
+	return b.String()
+}
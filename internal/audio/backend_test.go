@@ -0,0 +1,129 @@
+package audio
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseBackend(t *testing.T) {
+	b, err := ParseBackend("/usr/bin/aplay -q {file}", []string{"WAV"})
+	if err != nil {
+		t.Fatalf("ParseBackend failed: %v", err)
+	}
+	if b.Name != "aplay" {
+		t.Errorf("expected name aplay, got %q", b.Name)
+	}
+	if b.Stdin() {
+		t.Error("expected {file} template not to use stdin")
+	}
+	if got := b.args("/dev/fd/3", Stream{}, MaxVolume); len(got) != 2 || got[1] != "/dev/fd/3" {
+		t.Errorf("expected placeholder to be substituted, got %v", got)
+	}
+	if !b.Supports(FormatWAV) || b.Supports(FormatMP3) {
+		t.Errorf("expected wav-only support, got %v", b.Formats)
+	}
+
+	b, err = ParseBackend("mpv --volume=60 -", nil)
+	if err != nil {
+		t.Fatalf("ParseBackend failed: %v", err)
+	}
+	if !b.Stdin() {
+		t.Error("expected template without {file} to use stdin")
+	}
+	if !b.Supports(FormatMP3) || !b.Supports(FormatWAV) {
+		t.Errorf("expected default formats mp3 and wav, got %v", b.Formats)
+	}
+
+	b, err = ParseBackend("aplay -t raw -c {channels} -r {rate}", []string{FormatPCM})
+	if err != nil {
+		t.Fatalf("ParseBackend failed: %v", err)
+	}
+	got := strings.Join(b.args("", Stream{SampleRate: 22050, Channels: 2}, MaxVolume), " ")
+	if got != "-t raw -c 2 -r 22050" {
+		t.Errorf("expected PCM placeholders to be substituted, got %q", got)
+	}
+
+	b, err = ParseBackend("play --volume={volume} -v {gain} {file}", nil)
+	if err != nil {
+		t.Fatalf("ParseBackend failed: %v", err)
+	}
+	got = strings.Join(b.args("x.mp3", Stream{}, 50), " ")
+	if got != "--volume=50 -v 0.250 x.mp3" {
+		t.Errorf("expected volume placeholders to be substituted, got %q", got)
+	}
+
+	if _, err := ParseBackend("   ", nil); err == nil {
+		t.Error("expected error for empty command, got nil")
+	}
+
+	builtin := Backend{Name: "mci", Formats: []string{FormatMP3, FormatWAV}}
+	if got := builtin.args("x.mp3", Stream{}, MaxVolume); len(got) != 0 {
+		t.Errorf("expected no arguments for a built-in player, got %q", got)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		data     string
+		name     string
+		expected string
+	}{
+		{"RIFF\x00\x00\x00\x00WAVEfmt ", "sound.mp3", FormatWAV},
+		{"ID3\x03\x00", "sound.wav", FormatMP3},
+		{"\xff\xf3\x50\xc4", "sound", FormatMP3},
+		{"????", "sound.OGG", "ogg"},
+	}
+
+	for _, tc := range tests {
+		got := DetectFormat([]byte(tc.data), tc.name)
+		if got != tc.expected {
+			t.Errorf("DetectFormat(%q, %q) = %q, want %q", tc.data, tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestChooseBackend(t *testing.T) {
+	mpv := Backend{Name: "mpv", Command: []string{"mpv", "-"}, Formats: []string{FormatMP3, FormatWAV}}
+	aplay := Backend{Name: "aplay", Command: []string{"aplay", FilePlaceholder}, Formats: []string{FormatWAV}}
+	raw := Backend{Name: "raw", Command: []string{"aplay", "-t", "raw"}, Formats: []string{FormatPCM}}
+
+	tests := []struct {
+		name   string
+		probes []Probe
+		format string
+		want   string
+		given  string
+		err    bool
+	}{
+		{"first match", []Probe{{Backend: mpv, Path: "/bin/mpv"}, {Backend: aplay, Path: "/bin/aplay"}}, FormatMP3, "mpv", FormatMP3, false},
+		{"skip missing", []Probe{{Backend: mpv}, {Backend: aplay, Path: "/bin/aplay"}}, FormatWAV, "aplay", FormatWAV, false},
+		{"convert mp3", []Probe{{Backend: mpv}, {Backend: aplay, Path: "/bin/aplay"}}, FormatMP3, "aplay", FormatWAV, false},
+		{"decode mp3", []Probe{{Backend: mpv}, {Backend: raw, Path: "/bin/aplay"}, {Backend: aplay, Path: "/bin/aplay"}}, FormatMP3, "raw", FormatPCM, false},
+		{"decode wav", []Probe{{Backend: raw, Path: "/bin/aplay"}, {Backend: aplay, Path: "/bin/aplay"}}, FormatWAV, "raw", FormatPCM, false},
+		{"native before wav", []Probe{{Backend: aplay, Path: "/bin/aplay"}, {Backend: mpv, Path: "/bin/mpv"}}, FormatMP3, "mpv", FormatMP3, false},
+		{"unsupported", []Probe{{Backend: aplay, Path: "/bin/aplay"}}, "ogg", "", "", true},
+		{"none found", []Probe{{Backend: mpv}, {Backend: aplay}}, FormatMP3, "", "", true},
+	}
+
+	for _, tc := range tests {
+		c, err := chooseBackend(tc.probes, tc.format)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got %s", tc.name, c.Backend.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if c.Backend.Name != tc.want || c.Format != tc.given {
+			t.Errorf("%s: got %s given %s, want %s given %s", tc.name, c.Backend.Name, c.Format, tc.want, tc.given)
+		}
+	}
+
+	if _, err := chooseBackend([]Probe{{Backend: mpv}}, FormatMP3); !errors.Is(err, ErrNoPlayer) {
+		t.Errorf("expected ErrNoPlayer when nothing is installed, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package audio
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+)
+
+// Chime styles.
+const (
+	ChimeWestminster = "westminster"
+	ChimeBeep        = "beep"
+)
+
+// Bell pitches of the Westminster quarters, in Hz.
+const (
+	noteE3  = 164.81
+	noteB3  = 246.94
+	noteE4  = 329.63
+	noteFs4 = 369.99
+	noteGs4 = 415.30
+)
+
+// westminsterChanges are the five four-note changes the quarters are built
+// from.
+var westminsterChanges = [5][4]float64{
+	{noteGs4, noteFs4, noteE4, noteB3},
+	{noteE4, noteGs4, noteFs4, noteB3},
+	{noteE4, noteFs4, noteGs4, noteE4},
+	{noteGs4, noteE4, noteFs4, noteB3},
+	{noteB3, noteFs4, noteGs4, noteE4},
+}
+
+// westminsterQuarters lists the changes rung at each quarter; the fourth is
+// rung on the hour.
+var westminsterQuarters = [4][]int{
+	{0},
+	{1, 2},
+	{3, 4, 0},
+	{1, 2, 3, 4},
+}
+
+const (
+	chimeBeat   = 600 * time.Millisecond // between notes of a change
+	beepLength  = 150 * time.Millisecond
+	beepGap     = 150 * time.Millisecond
+	strikeRing  = 2500 * time.Millisecond // how long each hour strike lasts
+	strikeStart = time.Second             // pause between the quarters and the strikes
+)
+
+var (
+	bellEnvelope = Envelope{Attack: 5 * time.Millisecond, Decay: 500 * time.Millisecond, Release: 200 * time.Millisecond}
+	beepEnvelope = Envelope{Attack: 5 * time.Millisecond, Release: 20 * time.Millisecond}
+)
+
+// westminsterTones returns the quarter chime rung quarter quarters past the
+// hour, 4 being the hour itself.
+func westminsterTones(quarter int) []Tone {
+	var tones []Tone
+	for i, c := range westminsterQuarters[quarter-1] {
+		for j, freq := range westminsterChanges[c] {
+			// Each change is followed by a silent beat.
+			at := time.Duration(i*5+j) * chimeBeat
+			tones = append(tones, Tone{Freq: freq, At: at, Length: 4 * chimeBeat, Gain: 1, Timbre: Bell, Envelope: bellEnvelope})
+		}
+	}
+	return tones
+}
+
+// chimeSounds synthesizes the sounds in ChimeFS, by name.
+var chimeSounds = map[string]func() []Tone{
+	"westminster-1.wav": func() []Tone { return westminsterTones(1) },
+	"westminster-2.wav": func() []Tone { return westminsterTones(2) },
+	"westminster-3.wav": func() []Tone { return westminsterTones(3) },
+	"westminster-4.wav": func() []Tone { return westminsterTones(4) },
+	"westminster-strike.wav": func() []Tone {
+		env := bellEnvelope
+		env.Decay = 800 * time.Millisecond
+		return []Tone{{Freq: noteE3, Length: strikeRing, Gain: 1, Timbre: Bell, Envelope: env}}
+	},
+	"beep.wav": func() []Tone {
+		return []Tone{{Freq: 880, Length: beepLength, Gain: 1, Timbre: Sine, Envelope: beepEnvelope}}
+	},
+	"beep-hour.wav": func() []Tone {
+		return []Tone{
+			{Freq: 880, Length: beepLength, Gain: 1, Timbre: Sine, Envelope: beepEnvelope},
+			{Freq: 1320, At: beepLength + beepGap, Length: 2 * beepLength, Gain: 1, Timbre: Sine, Envelope: beepEnvelope},
+		}
+	},
+}
+
+// ChimeSequence returns what style plays quarter quarters past hour:
+// quarter is 0 on the hour, when the Westminster style also strikes the
+// hour on a 12-hour dial.
+func ChimeSequence(style string, hour, quarter int) (Sequence, error) {
+	if quarter < 0 || quarter > 3 {
+		return nil, fmt.Errorf("invalid quarter %d", quarter)
+	}
+	switch style {
+	case ChimeWestminster:
+		if quarter != 0 {
+			return Sequence{{Sound: fmt.Sprintf("westminster-%d.wav", quarter)}}, nil
+		}
+		seq := Sequence{{Sound: "westminster-4.wav"}, {Gap: strikeStart}}
+		strikes := hour % 12
+		if strikes == 0 {
+			strikes = 12
+		}
+		for i := 0; i < strikes; i++ {
+			seq = append(seq, Step{Sound: "westminster-strike.wav"})
+		}
+		return seq, nil
+	case ChimeBeep:
+		if quarter == 0 {
+			return Sequence{{Sound: "beep-hour.wav"}}, nil
+		}
+		var seq Sequence
+		for i := 0; i < quarter; i++ {
+			if i > 0 {
+				seq = append(seq, Step{Gap: beepGap})
+			}
+			seq = append(seq, Step{Sound: "beep.wav"})
+		}
+		return seq, nil
+	default:
+		return nil, fmt.Errorf("unknown chime style %q", style)
+	}
+}
+
+// chimeGrace is how late a chime may still be rung, so that starting the
+// clock partway through a quarter hour does not chime.
+const chimeGrace = 5 * time.Second
+
+// ChimeSchedule decides when chimes are due. The zero value never chimes.
+type ChimeSchedule struct {
+	Style    string // ChimeWestminster, ChimeBeep, or empty for none
+	Quarters bool   // chime at quarter hours as well as on the hour
+
+	last time.Time // start of the last quarter hour considered
+}
+
+// Due returns the chime for the quarter hour now falls in, the first time it
+// is called within chimeGrace of that quarter hour starting.
+func (c *ChimeSchedule) Due(now time.Time) (Sequence, bool) {
+	if c.Style == "" {
+		return nil, false
+	}
+	q := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute()/15*15, 0, 0, now.Location())
+	if q.Equal(c.last) {
+		return nil, false
+	}
+	c.last = q
+	if now.Sub(q) > chimeGrace || (!c.Quarters && q.Minute() != 0) {
+		return nil, false
+	}
+	seq, err := ChimeSequence(c.Style, q.Hour(), q.Minute()/15)
+	if err != nil {
+		return nil, false
+	}
+	return seq, true
+}
+
+// ChimeFS is a read-only filesystem of the synthesized sounds named by
+// ChimeSequence. Each sound is synthesized when it is opened.
+type ChimeFS struct{}
+
+// Open implements fs.FS.
+func (ChimeFS) Open(name string) (fs.File, error) {
+	synth, ok := chimeSounds[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	data := SynthWAV(SynthRate, synth())
+	return MemFile(name, data), nil
+}
+
+// MemFile returns a read-only fs.File named name that reads data from
+// memory, for filesystems of sounds that are not on disk.
+func MemFile(name string, data []byte) fs.File {
+	return &memFile{Reader: bytes.NewReader(data), name: name}
+}
+
+// memFile is an fs.File whose contents are held in memory. Its Size comes
+// from the bytes.Reader.
+type memFile struct {
+	*bytes.Reader
+	name string
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memFile is its own fs.FileInfo.
+func (f *memFile) Name() string       { return path.Base(f.name) }
+func (f *memFile) Mode() fs.FileMode  { return 0444 }
+func (f *memFile) ModTime() time.Time { return time.Time{} }
+func (f *memFile) IsDir() bool        { return false }
+func (f *memFile) Sys() any           { return nil }
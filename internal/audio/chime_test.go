@@ -0,0 +1,100 @@
+package audio
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestChimeSequence(t *testing.T) {
+	tests := []struct {
+		style   string
+		hour    int
+		quarter int
+		sounds  int
+		gaps    int
+	}{
+		{ChimeWestminster, 15, 0, 4, 1}, // hour phrase and three strikes
+		{ChimeWestminster, 0, 0, 13, 1},
+		{ChimeWestminster, 9, 2, 1, 0},
+		{ChimeBeep, 9, 0, 1, 0},
+		{ChimeBeep, 9, 3, 3, 2},
+	}
+	for _, tc := range tests {
+		seq, err := ChimeSequence(tc.style, tc.hour, tc.quarter)
+		if err != nil {
+			t.Fatalf("ChimeSequence(%s, %d, %d) failed: %v", tc.style, tc.hour, tc.quarter, err)
+		}
+		sounds, gaps := 0, 0
+		for _, st := range seq {
+			if st.Sound == "" {
+				gaps++
+				continue
+			}
+			sounds++
+			if _, ok := chimeSounds[st.Sound]; !ok {
+				t.Errorf("%s: sequence names unknown sound %q", tc.style, st.Sound)
+			}
+		}
+		if sounds != tc.sounds || gaps != tc.gaps {
+			t.Errorf("%s at %d:%02d: got %d sounds and %d gaps, want %d and %d",
+				tc.style, tc.hour, tc.quarter*15, sounds, gaps, tc.sounds, tc.gaps)
+		}
+	}
+
+	if _, err := ChimeSequence("cuckoo", 9, 0); err == nil {
+		t.Error("expected error for unknown style, got nil")
+	}
+}
+
+func TestChimeSchedule_Due(t *testing.T) {
+	at := func(clock string) time.Time {
+		tm, err := time.Parse("15:04:05.000", clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	c := &ChimeSchedule{Style: ChimeBeep}
+	if _, ok := c.Due(at("10:07:00.000")); ok {
+		t.Error("expected no chime partway through a quarter hour")
+	}
+	if _, ok := c.Due(at("10:15:00.100")); ok {
+		t.Error("expected no quarter chime unless Quarters is set")
+	}
+	if _, ok := c.Due(at("11:00:00.100")); !ok {
+		t.Error("expected a chime on the hour")
+	}
+	if _, ok := c.Due(at("11:00:00.200")); ok {
+		t.Error("expected the hour to chime only once")
+	}
+
+	c = &ChimeSchedule{Style: ChimeBeep, Quarters: true}
+	if seq, ok := c.Due(at("11:30:01.000")); !ok || len(seq) != 3 {
+		t.Errorf("expected two beeps at half past, got %v, %v", seq, ok)
+	}
+
+	var none ChimeSchedule
+	if _, ok := none.Due(at("12:00:00.000")); ok {
+		t.Error("expected the zero schedule never to chime")
+	}
+}
+
+func TestChimeFS(t *testing.T) {
+	for name := range chimeSounds {
+		data, err := fs.ReadFile(ChimeFS{}, name)
+		if err != nil {
+			t.Errorf("read %s: %v", name, err)
+			continue
+		}
+		if d := wavDuration(data); d <= 0 {
+			t.Errorf("%s: expected a playable WAV, got duration %v", name, d)
+		}
+	}
+
+	if _, err := (ChimeFS{}).Open("cuckoo.wav"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist for an unknown sound, got %v", err)
+	}
+}
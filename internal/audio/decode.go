@@ -0,0 +1,100 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// Decoded audio is always 16-bit stereo.
+const (
+	pcmChannels      = 2
+	pcmBitsPerSample = 16
+	pcmFrameSize     = pcmChannels * pcmBitsPerSample / 8
+)
+
+// decodeChunk is how many bytes of PCM are handed to a sink at a time.
+const decodeChunk = 4096 * pcmFrameSize
+
+// Stream describes decoded PCM audio: interleaved signed 16-bit
+// little-endian samples.
+type Stream struct {
+	SampleRate int
+	Channels   int
+	Size       int64 // bytes of PCM, or -1 if unknown
+}
+
+// BytesPerSecond returns how many bytes of PCM make up one second.
+func (s Stream) BytesPerSecond() int {
+	return s.SampleRate * s.Channels * pcmBitsPerSample / 8
+}
+
+// Duration returns how long n bytes of the stream last.
+func (s Stream) Duration(n int64) time.Duration {
+	bps := s.BytesPerSecond()
+	if bps == 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second / time.Duration(bps)
+}
+
+// Sink consumes decoded PCM audio.
+type Sink interface {
+	// Open is called once, before any samples are written.
+	Open(s Stream) error
+	// Write receives the next samples, always whole frames.
+	Write(pcm []byte) (int, error)
+	// Close is called once decoding ends, successfully or not.
+	Close() error
+}
+
+// mp3Stream returns the format an MP3 decodes to, without decoding it.
+func mp3Stream(data []byte) (Stream, error) {
+	dec, err := mp3.NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		return Stream{}, fmt.Errorf("decode mp3: %w", err)
+	}
+	return Stream{SampleRate: dec.SampleRate(), Channels: pcmChannels, Size: dec.Length()}, nil
+}
+
+// DecodeMP3 decodes an MP3 into sink and closes the sink. It stops early if
+// ctx is cancelled. The stream size is only known when r is an io.Seeker.
+func DecodeMP3(ctx context.Context, r io.Reader, sink Sink) error {
+	dec, err := mp3.NewDecoder(r)
+	if err != nil {
+		sink.Close()
+		return fmt.Errorf("decode mp3: %w", err)
+	}
+	s := Stream{SampleRate: dec.SampleRate(), Channels: pcmChannels, Size: dec.Length()}
+	if err := sink.Open(s); err != nil {
+		sink.Close()
+		return err
+	}
+
+	buf := make([]byte, decodeChunk)
+	for {
+		if err := ctx.Err(); err != nil {
+			sink.Close()
+			return err
+		}
+		n, err := io.ReadFull(dec, buf)
+		if n > 0 {
+			if _, werr := sink.Write(buf[:n]); werr != nil {
+				sink.Close()
+				return werr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			sink.Close()
+			return fmt.Errorf("decode mp3: %w", err)
+		}
+	}
+	return sink.Close()
+}
@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// shortPCMSHA256 is the SHA-256 of testdata/short.mp3 decoded to PCM.
+const shortPCMSHA256 = "5e1a4449de3ea2a596b332c7cb9d380c966d40060f65fd57f3f49b3e71907a41"
+
+// shortPCMSize is 60 MPEG-2 frames of 576 stereo 16-bit samples.
+const shortPCMSize = 60 * 576 * 4
+
+func readShortMP3(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/short.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeMP3_BitExact(t *testing.T) {
+	var pcm bytes.Buffer
+	if err := DecodeMP3(context.Background(), bytes.NewReader(readShortMP3(t)), NewPipeSink(&pcm)); err != nil {
+		t.Fatalf("DecodeMP3 failed: %v", err)
+	}
+	if pcm.Len() != shortPCMSize {
+		t.Errorf("expected %d bytes of PCM, got %d", shortPCMSize, pcm.Len())
+	}
+	sum := sha256.Sum256(pcm.Bytes())
+	if got := hex.EncodeToString(sum[:]); got != shortPCMSHA256 {
+		t.Errorf("decoded PCM hash = %s, want %s", got, shortPCMSHA256)
+	}
+}
+
+func TestDecodeMP3_NullSink(t *testing.T) {
+	var sink NullSink
+	if err := DecodeMP3(context.Background(), bytes.NewReader(readShortMP3(t)), &sink); err != nil {
+		t.Fatalf("DecodeMP3 failed: %v", err)
+	}
+	if s := sink.Stream(); s.SampleRate != 22050 || s.Channels != 2 || s.Size != shortPCMSize {
+		t.Errorf("unexpected stream %+v", s)
+	}
+	want := 60 * 576 * time.Second / 22050
+	if got := sink.Duration(); got != want {
+		t.Errorf("expected duration %v, got %v", want, got)
+	}
+}
+
+func TestDecodeMP3_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var sink NullSink
+	if err := DecodeMP3(ctx, bytes.NewReader(readShortMP3(t)), &sink); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if sink.Bytes() != 0 {
+		t.Errorf("expected nothing decoded after cancel, got %d bytes", sink.Bytes())
+	}
+}
+
+func TestDecodeMP3_Invalid(t *testing.T) {
+	var sink NullSink
+	if err := DecodeMP3(context.Background(), bytes.NewReader([]byte("not an mp3")), &sink); err == nil {
+		t.Error("expected error for invalid MP3, got nil")
+	}
+}
+
+func TestWAVSink_PatchesUnknownSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A plain reader hides the size from the decoder.
+	r := struct{ *bytes.Reader }{bytes.NewReader(readShortMP3(t))}
+	if err := DecodeMP3(context.Background(), r, NewWAVSink(f)); err != nil {
+		t.Fatalf("DecodeMP3 failed: %v", err)
+	}
+
+	wav, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wav) != wavHeaderSize+shortPCMSize {
+		t.Fatalf("expected %d bytes, got %d", wavHeaderSize+shortPCMSize, len(wav))
+	}
+	if size := binary.LittleEndian.Uint32(wav[4:]); size != 36+shortPCMSize {
+		t.Errorf("expected RIFF size %d, got %d", 36+shortPCMSize, size)
+	}
+	if size := binary.LittleEndian.Uint32(wav[40:]); size != shortPCMSize {
+		t.Errorf("expected data size %d, got %d", shortPCMSize, size)
+	}
+}
+
+func TestMP3ToWAV(t *testing.T) {
+	wav, err := mp3ToWAV(readShortMP3(t))
+	if err != nil {
+		t.Fatalf("mp3ToWAV failed: %v", err)
+	}
+	if DetectFormat(wav, "") != FormatWAV {
+		t.Fatalf("expected a WAV header, got %q", wav[:12])
+	}
+	if rate := binary.LittleEndian.Uint32(wav[24:]); rate != 22050 {
+		t.Errorf("expected 22050 Hz, got %d", rate)
+	}
+	if size := binary.LittleEndian.Uint32(wav[40:]); size != shortPCMSize || int(size) != len(wav)-wavHeaderSize {
+		t.Errorf("unexpected data size %d for %d bytes of WAV", size, len(wav))
+	}
+	sum := sha256.Sum256(wav[wavHeaderSize:])
+	if got := hex.EncodeToString(sum[:]); got != shortPCMSHA256 {
+		t.Errorf("WAV samples hash = %s, want %s", got, shortPCMSHA256)
+	}
+}
+
+func TestSoundDuration(t *testing.T) {
+	data := readShortMP3(t)
+	want := Stream{SampleRate: 22050, Channels: 2}.Duration(shortPCMSize)
+
+	mp3 := &sound{format: FormatMP3, data: data}
+	if got := mp3.duration(); got != want {
+		t.Errorf("MP3 duration = %v, want %v", got, want)
+	}
+
+	wav, err := mp3ToWAV(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &sound{format: FormatWAV, data: wav}
+	if got := w.duration(); got != want {
+		t.Errorf("WAV duration = %v, want %v", got, want)
+	}
+
+	bad := &sound{format: FormatMP3, data: []byte("not really an mp3")}
+	if got := bad.duration(); got != 0 {
+		t.Errorf("expected unknown duration for invalid MP3, got %v", got)
+	}
+}
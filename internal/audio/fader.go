@@ -0,0 +1,215 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+)
+
+// MaxVolume is full scale; volumes run from 0 (silent) to MaxVolume.
+const MaxVolume = 100
+
+// volumeSmoothing is how long a volume change takes to ramp in, so that
+// adjusting it mid-sound does not click.
+const volumeSmoothing = 20 * time.Millisecond
+
+// clampVolume limits v to 0..MaxVolume.
+func clampVolume(v int) int {
+	return max(0, min(v, MaxVolume))
+}
+
+// gainFor converts a volume to a linear gain. Squaring the fraction roughly
+// matches how loud it sounds.
+func gainFor(volume int) float64 {
+	f := float64(clampVolume(volume)) / MaxVolume
+	return f * f
+}
+
+// volumeControl adjusts a sound while it plays.
+type volumeControl interface {
+	SetVolume(volume int)
+	// FadeOut fades the sound to silence over d. The returned channel is
+	// closed once the fade has been heard.
+	FadeOut(d time.Duration) <-chan struct{}
+}
+
+// Fader is a Sink that applies a volume, a fade-in and, on request, a
+// fade-out to PCM on its way to another sink. The volume can be changed
+// while audio is flowing.
+type Fader struct {
+	next   Sink
+	buf    []byte
+	volume int
+	fadeIn time.Duration
+
+	mu       sync.Mutex
+	stream   Stream
+	target   float64 // gain set by SetVolume
+	gain     float64 // gain applied to the last frame, ramping to target
+	step     float64 // largest gain change per frame
+	frames   int64   // frames written so far
+	inFrames int64   // length of the fade-in in frames
+
+	fading   bool
+	outLen   int64 // length of the fade-out in frames, once known
+	outLeft  int64 // fade-out frames still to write
+	tailLeft int64 // silent frames to write after the fade-out
+	tail     time.Duration
+	outOver  bool // fade and tail have been processed
+	outDone  chan struct{}
+}
+
+// NewFader creates a Fader that starts at volume, fading in over fadeIn.
+func NewFader(next Sink, volume int, fadeIn time.Duration) *Fader {
+	return &Fader{next: next, volume: clampVolume(volume), fadeIn: fadeIn}
+}
+
+// Open implements Sink.
+func (f *Fader) Open(s Stream) error {
+	f.mu.Lock()
+	f.stream = s
+	f.target = gainFor(f.volume)
+	f.gain = f.target
+	f.step = 1 / (float64(s.SampleRate) * volumeSmoothing.Seconds())
+	f.inFrames = int64(f.fadeIn.Seconds() * float64(s.SampleRate))
+	f.mu.Unlock()
+	return f.next.Open(s)
+}
+
+// SetVolume changes the volume of the audio still to be written.
+func (f *Fader) SetVolume(volume int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.volume = clampVolume(volume)
+	f.target = gainFor(f.volume)
+	if f.stream.SampleRate == 0 {
+		f.gain = f.target
+	}
+}
+
+// FadeOut implements volumeControl. The silence that follows the fade lasts
+// tail, the time written audio may take to reach the speaker.
+func (f *Fader) FadeOut(d time.Duration) <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.fading {
+		f.fading = true
+		f.outDone = make(chan struct{})
+		f.outLen = max(1, int64(d.Seconds()*float64(f.stream.SampleRate)))
+		f.outLeft = f.outLen
+		f.tailLeft = int64(f.tail.Seconds() * float64(f.stream.SampleRate))
+	}
+	return f.outDone
+}
+
+// Write implements Sink.
+func (f *Fader) Write(pcm []byte) (int, error) {
+	if cap(f.buf) < len(pcm) {
+		f.buf = make([]byte, len(pcm))
+	}
+	out := f.buf[:len(pcm)]
+
+	f.mu.Lock()
+	channels := max(1, f.stream.Channels)
+	frameSize := channels * pcmBitsPerSample / 8
+	for i := 0; i+frameSize <= len(pcm); i += frameSize {
+		g := f.nextGain()
+		for c := 0; c < channels; c++ {
+			at := i + 2*c
+			v := float64(int16(binary.LittleEndian.Uint16(pcm[at:]))) * g
+			v = math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(v)))
+			binary.LittleEndian.PutUint16(out[at:], uint16(int16(v)))
+		}
+	}
+	over := f.outOver
+	f.mu.Unlock()
+
+	_, err := f.next.Write(out)
+	if over {
+		f.finishFade()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(pcm), nil
+}
+
+// nextGain advances by one frame and returns its gain. f.mu must be held.
+func (f *Fader) nextGain() float64 {
+	switch {
+	case f.gain < f.target:
+		f.gain = math.Min(f.target, f.gain+f.step)
+	case f.gain > f.target:
+		f.gain = math.Max(f.target, f.gain-f.step)
+	}
+	g := f.gain
+	if f.frames < f.inFrames {
+		g *= float64(f.frames) / float64(f.inFrames)
+	}
+	f.frames++
+
+	if f.fading {
+		switch {
+		case f.outLeft > 0:
+			f.outLeft--
+			g *= float64(f.outLeft) / float64(f.outLen)
+		case f.tailLeft > 0:
+			f.tailLeft--
+			g = 0
+		default:
+			g = 0
+			f.outOver = true
+		}
+	}
+	return g
+}
+
+// finishFade reports a fade-out, if any, as done.
+func (f *Fader) finishFade() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fading {
+		select {
+		case <-f.outDone:
+		default:
+			close(f.outDone)
+		}
+	}
+}
+
+// Close implements Sink. A pending fade-out counts as finished.
+func (f *Fader) Close() error {
+	f.finishFade()
+	return f.next.Close()
+}
+
+// pacedSink holds writes back to real time plus lead, so that changes made
+// upstream, like volume and fades, are heard promptly.
+type pacedSink struct {
+	next    Sink
+	lead    time.Duration
+	stream  Stream
+	start   time.Time
+	written int64
+}
+
+// Open implements Sink.
+func (p *pacedSink) Open(s Stream) error {
+	p.stream = s
+	p.start = time.Now()
+	return p.next.Open(s)
+}
+
+// Write implements Sink.
+func (p *pacedSink) Write(pcm []byte) (int, error) {
+	if ahead := p.stream.Duration(p.written) - time.Since(p.start); ahead > p.lead {
+		time.Sleep(ahead - p.lead)
+	}
+	n, err := p.next.Write(pcm)
+	p.written += int64(n)
+	return n, err
+}
+
+// Close implements Sink.
+func (p *pacedSink) Close() error { return p.next.Close() }
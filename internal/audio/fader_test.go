@@ -0,0 +1,147 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// captureSink keeps everything written to it.
+type captureSink struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *captureSink) Open(s Stream) error { return nil }
+func (c *captureSink) Close() error        { c.closed = true; return nil }
+
+// faderStream is mono at 1 kHz so that frame counts are easy to follow.
+var faderStream = Stream{SampleRate: 1000, Channels: 1, Size: -1}
+
+// constantPCM returns n mono frames of value v.
+func constantPCM(n int, v int16) []byte {
+	pcm := make([]byte, 2*n)
+	for i := 0; i < n; i++ {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(v))
+	}
+	return pcm
+}
+
+// samples decodes mono PCM.
+func samples(pcm []byte) []int16 {
+	s := make([]int16, len(pcm)/2)
+	for i := range s {
+		s[i] = int16(binary.LittleEndian.Uint16(pcm[2*i:]))
+	}
+	return s
+}
+
+func TestFader_Volume(t *testing.T) {
+	out := &captureSink{}
+	f := NewFader(out, 50, 0)
+	if err := f.Open(faderStream); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(constantPCM(10, 10000)); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range samples(out.Bytes()) {
+		if v != 2500 {
+			t.Fatalf("sample %d = %d, want 2500 at half volume", i, v)
+		}
+	}
+}
+
+func TestFader_SetVolumeRamps(t *testing.T) {
+	out := &captureSink{}
+	f := NewFader(out, MaxVolume, 0)
+	f.Open(faderStream)
+	f.SetVolume(0)
+	f.Write(constantPCM(40, 10000))
+
+	s := samples(out.Bytes())
+	if s[0] == 0 {
+		t.Error("expected volume change to ramp, not jump")
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] > s[i-1] {
+			t.Fatalf("sample %d rose from %d to %d while ramping down", i, s[i-1], s[i])
+		}
+	}
+	// volumeSmoothing is 20 frames at 1 kHz.
+	if s[len(s)-1] != 0 {
+		t.Errorf("expected silence after the ramp, got %d", s[len(s)-1])
+	}
+}
+
+func TestFader_FadeIn(t *testing.T) {
+	out := &captureSink{}
+	f := NewFader(out, MaxVolume, 100*time.Millisecond)
+	f.Open(faderStream)
+	f.Write(constantPCM(200, 10000))
+
+	s := samples(out.Bytes())
+	if s[0] != 0 {
+		t.Errorf("expected fade-in to start silent, got %d", s[0])
+	}
+	if s[50] != 5000 {
+		t.Errorf("expected half gain halfway through the fade-in, got %d", s[50])
+	}
+	if s[150] != 10000 {
+		t.Errorf("expected full gain after the fade-in, got %d", s[150])
+	}
+}
+
+func TestFader_FadeOut(t *testing.T) {
+	out := &captureSink{}
+	f := NewFader(out, MaxVolume, 0)
+	f.tail = 20 * time.Millisecond
+	f.Open(faderStream)
+	f.Write(constantPCM(10, 10000))
+
+	done := f.FadeOut(50 * time.Millisecond)
+	f.Write(constantPCM(60, 10000))
+	select {
+	case <-done:
+		t.Fatal("fade-out reported done before the silent tail was written")
+	default:
+	}
+	f.Write(constantPCM(20, 10000))
+	select {
+	case <-done:
+	default:
+		t.Fatal("expected fade-out to be done after fade and tail")
+	}
+
+	s := samples(out.Bytes())
+	if s[9] != 10000 || s[10] >= 10000 {
+		t.Errorf("expected fade-out to start at frame 10, got %d then %d", s[9], s[10])
+	}
+	for i := 60; i < len(s); i++ {
+		if s[i] != 0 {
+			t.Fatalf("sample %d = %d, want silence after the fade-out", i, s[i])
+		}
+	}
+	if f.FadeOut(time.Second) != done {
+		t.Error("expected a second FadeOut to return the same channel")
+	}
+}
+
+func TestFader_CloseEndsFadeOut(t *testing.T) {
+	out := &captureSink{}
+	f := NewFader(out, MaxVolume, 0)
+	f.Open(faderStream)
+	done := f.FadeOut(time.Second)
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Error("expected Close to end a pending fade-out")
+	}
+	if !out.closed {
+		t.Error("expected Close to close the next sink")
+	}
+}
@@ -0,0 +1,115 @@
+package audio
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// FakePlayer is a Player that makes no sound. It records every sound it is
+// asked to play and stays Playing until Finish, Stop or context cancellation,
+// which makes it suitable for tests.
+type FakePlayer struct {
+	mu     sync.Mutex
+	state  State
+	sound  string
+	played []string
+	volume int
+	start  time.Time
+	length time.Duration
+	stop   func() bool
+	events chan Event
+}
+
+// NewFakePlayer creates an idle FakePlayer.
+func NewFakePlayer() *FakePlayer {
+	return &FakePlayer{volume: MaxVolume, events: make(chan Event, eventBuffer)}
+}
+
+// Play implements Player. The sound is not read from fsys.
+func (f *FakePlayer) Play(ctx context.Context, fsys fs.FS, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.state == Playing {
+		return ErrBusy
+	}
+	f.state = Playing
+	f.sound = name
+	f.start = time.Now()
+	f.played = append(f.played, name)
+	f.stop = context.AfterFunc(ctx, func() { f.end(EventStopped) })
+	sendEvent(f.events, Event{Kind: EventStarted, Sound: name})
+	return nil
+}
+
+// Finish ends the current sound as if it had played to completion.
+func (f *FakePlayer) Finish() {
+	f.end(EventFinished)
+}
+
+// Stop implements Player.
+func (f *FakePlayer) Stop() {
+	f.end(EventStopped)
+}
+
+func (f *FakePlayer) end(kind EventKind) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.state != Playing {
+		return
+	}
+	f.stop()
+	f.state = Idle
+	sendEvent(f.events, Event{Kind: kind, Sound: f.sound})
+}
+
+// State implements Player.
+func (f *FakePlayer) State() State {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.state
+}
+
+// SetVolume implements Player.
+func (f *FakePlayer) SetVolume(volume int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.volume = clampVolume(volume)
+}
+
+// Volume implements Player.
+func (f *FakePlayer) Volume() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.volume
+}
+
+// SetDuration sets the duration Progress reports for every sound.
+func (f *FakePlayer) SetDuration(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.length = d
+}
+
+// Progress implements Player. The position is the time since Play.
+func (f *FakePlayer) Progress() Progress {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.state != Playing {
+		return Progress{}
+	}
+	return progressAt(f.sound, f.start, time.Now(), f.length)
+}
+
+// Events implements Player.
+func (f *FakePlayer) Events() <-chan Event {
+	return f.events
+}
+
+// Played returns the names of all sounds passed to Play, in order.
+func (f *FakePlayer) Played() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.played...)
+}
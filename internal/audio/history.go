@@ -0,0 +1,55 @@
+package audio
+
+import (
+	"fmt"
+	"sync"
+)
+
+// String describes the event, e.g. "azan1.mp3 failed: no audio player found".
+func (e Event) String() string {
+	if e.Kind == EventFailed && e.Err != nil {
+		return fmt.Sprintf("%s %s: %v", e.Sound, e.Kind, e.Err)
+	}
+	return fmt.Sprintf("%s %s", e.Sound, e.Kind)
+}
+
+// History keeps the most recent playback events. It is safe for concurrent
+// use.
+type History struct {
+	mu     sync.Mutex
+	size   int
+	events []Event
+}
+
+// NewHistory creates a History that keeps the last size events.
+func NewHistory(size int) *History {
+	return &History{size: max(1, size)}
+}
+
+// Add records e, dropping the oldest event if the history is full.
+func (h *History) Add(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.events) == h.size {
+		copy(h.events, h.events[1:])
+		h.events = h.events[:h.size-1]
+	}
+	h.events = append(h.events, e)
+}
+
+// Events returns the recorded events, oldest first.
+func (h *History) Events() []Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Event(nil), h.events...)
+}
+
+// Last returns the most recent event, if any.
+func (h *History) Last() (Event, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.events) == 0 {
+		return Event{}, false
+	}
+	return h.events[len(h.events)-1], true
+}
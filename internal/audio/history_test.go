@@ -0,0 +1,42 @@
+package audio
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHistory_KeepsMostRecent(t *testing.T) {
+	h := NewHistory(2)
+	if _, ok := h.Last(); ok {
+		t.Error("expected no last event in an empty history")
+	}
+
+	h.Add(Event{Kind: EventStarted, Sound: "azan.mp3"})
+	h.Add(Event{Kind: EventFinished, Sound: "azan.mp3"})
+	h.Add(Event{Kind: EventFailed, Sound: "dua.mp3", Err: ErrNoPlayer})
+
+	got := h.Events()
+	if len(got) != 2 || got[0].Kind != EventFinished || got[1].Kind != EventFailed {
+		t.Fatalf("expected the last two events, got %v", got)
+	}
+	if last, ok := h.Last(); !ok || last.Sound != "dua.mp3" {
+		t.Errorf("expected last event for dua.mp3, got %v", last)
+	}
+}
+
+func TestEvent_String(t *testing.T) {
+	tests := []struct {
+		e    Event
+		want string
+	}{
+		{Event{Kind: EventStarted, Sound: "azan.mp3"}, "azan.mp3 started"},
+		{Event{Kind: EventStopped, Sound: "azan.mp3"}, "azan.mp3 stopped"},
+		{Event{Kind: EventFailed, Sound: "azan.mp3", Err: ErrNoPlayer}, "azan.mp3 failed: no audio player found"},
+		{Event{Kind: EventFailed, Sound: "azan.mp3", Err: errors.New("mpv: exit status 2")}, "azan.mp3 failed: mpv: exit status 2"},
+	}
+	for _, tc := range tests {
+		if got := tc.e.String(); got != tc.want {
+			t.Errorf("String() = %q, want %q", got, tc.want)
+		}
+	}
+}
@@ -1,117 +1,472 @@
 package audio
 
 import (
-	"embed"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"runtime"
+	"path"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
 )
 
-var (
-	tempFile string
-	once     sync.Once
-	playing  bool
-	mu       sync.Mutex
+// ErrBusy is returned by Play when a sound is already playing.
+var ErrBusy = errors.New("audio: already playing")
+
+// ErrNoPlayer is reported when no usable audio player can be found.
+var ErrNoPlayer = errors.New("no audio player found")
+
+// State describes what a Player is currently doing.
+type State int
+
+const (
+	Idle State = iota
+	Playing
 )
 
-// extractToTemp writes the embedded MP3 to a temp file (once) and returns the path.
-func extractToTemp(fs embed.FS, filename string) (string, error) {
-	var extractErr error
-	once.Do(func() {
-		data, err := fs.ReadFile(filename)
-		if err != nil {
-			extractErr = fmt.Errorf("read embedded file: %w", err)
-			return
-		}
-		tmp := filepath.Join(os.TempDir(), "azan_clock.mp3")
-		if err := os.WriteFile(tmp, data, 0644); err != nil {
-			extractErr = fmt.Errorf("write temp file: %w", err)
-			return
+// String returns a short lowercase name for the state.
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Playing:
+		return "playing"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// EventKind identifies what happened to a sound.
+type EventKind int
+
+const (
+	EventStarted EventKind = iota
+	EventFinished
+	EventStopped
+	EventFailed
+)
+
+// String returns a short lowercase name for the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventStarted:
+		return "started"
+	case EventFinished:
+		return "finished"
+	case EventStopped:
+		return "stopped"
+	case EventFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+}
+
+// Event reports a change in playback.
+type Event struct {
+	Kind  EventKind
+	Sound string
+	Err   error // set for EventFailed
+	Time  time.Time
+}
+
+// Progress describes how far the current sound has played.
+type Progress struct {
+	Sound    string
+	Position time.Duration
+	Duration time.Duration // 0 if unknown
+}
+
+// Fraction returns how much of the sound has played, from 0 to 1, or 0 if
+// its duration is unknown.
+func (p Progress) Fraction() float64 {
+	if p.Duration <= 0 {
+		return 0
+	}
+	return min(1, float64(p.Position)/float64(p.Duration))
+}
+
+// progressAt returns the progress at now of a sound started at start.
+func progressAt(name string, start, now time.Time, duration time.Duration) Progress {
+	pos := now.Sub(start)
+	if duration > 0 {
+		pos = min(pos, duration)
+	}
+	return Progress{Sound: name, Position: pos, Duration: duration}
+}
+
+// Player plays sounds stored in a filesystem.
+type Player interface {
+	// Play starts playing name from fsys and returns immediately. Playback
+	// continues until the sound ends, Stop is called or ctx is cancelled.
+	// It returns ErrBusy if another sound is still playing.
+	Play(ctx context.Context, fsys fs.FS, name string) error
+	// Stop stops the current sound, if any, and waits for it to end.
+	Stop()
+	// State reports whether a sound is playing.
+	State() State
+	// SetVolume sets the volume, 0 to MaxVolume, of the current sound where
+	// the backend allows it and of sounds played from now on.
+	SetVolume(volume int)
+	// Volume returns the volume set by SetVolume.
+	Volume() int
+	// Progress reports the sound playing and how far it has got, or the
+	// zero Progress when idle.
+	Progress() Progress
+	// Events returns a channel of playback events. Events are dropped if
+	// nobody is receiving.
+	Events() <-chan Event
+}
+
+// eventBuffer is how many events a player queues before dropping them.
+const eventBuffer = 16
+
+// ExecPlayer plays sounds through the platform's audio player: on Unix the
+// first of its backends that can decode the sound, and MCI on Windows.
+// Sounds are streamed to the player's standard input where it can read one;
+// otherwise they are copied to private temp files that are removed as early
+// as the platform allows.
+type ExecPlayer struct {
+	mu       sync.Mutex
+	state    State
+	cancel   context.CancelFunc
+	done     chan struct{}
+	proc     *os.Process   // running player process, if any
+	control  volumeControl // live control of the current sound, if any
+	events   chan Event
+	backends []Backend
+	current  *sound    // sound being played, if any
+	start    time.Time // when the current sound started
+	clock    chrono.Clock
+
+	volume  int
+	fadeIn  time.Duration
+	fadeOut time.Duration
+
+	sounds map[string]*sound // loaded sounds, keyed by name
+}
+
+// playback is one sound being played by an ExecPlayer.
+type playback struct {
+	snd      *sound
+	backends []Backend
+	volume   int
+	fadeIn   time.Duration
+	started  func(*os.Process)   // called once a player process is running
+	control  func(volumeControl) // called if the sound can be adjusted live
+	mu       *sync.Mutex         // the player's, held to use snd.path
+}
+
+// sound is a sound's bytes, read once from its filesystem.
+type sound struct {
+	name   string
+	format string
+	data   []byte
+	path   string // private temp copy for backends that need a named file; guarded by ExecPlayer.mu
+
+	wavOnce sync.Once
+	wav     []byte
+	wavErr  error
+
+	durOnce sync.Once
+	dur     time.Duration
+}
+
+// duration returns how long the sound plays, or 0 if that cannot be told
+// from its data. It is worked out the first time it is needed.
+func (s *sound) duration() time.Duration {
+	s.durOnce.Do(func() {
+		switch s.format {
+		case FormatMP3:
+			if st, err := mp3Stream(s.data); err == nil && st.Size > 0 {
+				s.dur = st.Duration(st.Size)
+			}
+		case FormatWAV:
+			s.dur = wavDuration(s.data)
 		}
-		tempFile = tmp
 	})
-	return tempFile, extractErr
+	return s.dur
+}
+
+// stream returns the PCM format the sound decodes to.
+func (s *sound) stream() (Stream, error) {
+	if s.format == FormatWAV {
+		st, _, err := parseWAV(s.data)
+		return st, err
+	}
+	return mp3Stream(s.data)
 }
 
-// Play plays the azan MP3 from the embedded filesystem.
-// It's non-blocking and prevents overlapping playback.
-func Play(fs embed.FS, filename string) error {
-	mu.Lock()
-	if playing {
-		mu.Unlock()
-		return nil
+// decode feeds the sound's samples into sink and closes it.
+func (s *sound) decode(ctx context.Context, sink Sink) error {
+	if s.format == FormatWAV {
+		return DecodeWAV(ctx, bytes.NewReader(s.data), sink)
 	}
-	playing = true
-	mu.Unlock()
+	return DecodeMP3(ctx, bytes.NewReader(s.data), sink)
+}
+
+// asWAV returns the sound converted to WAV, converting it the first time.
+func (s *sound) asWAV() ([]byte, error) {
+	s.wavOnce.Do(func() {
+		s.wav, s.wavErr = mp3ToWAV(s.data)
+	})
+	return s.wav, s.wavErr
+}
+
+// NewExecPlayer creates an idle ExecPlayer that tries backends in order.
+// A nil backends means DefaultBackends. Backends are ignored on Windows.
+func NewExecPlayer(backends []Backend) *ExecPlayer {
+	return NewExecPlayerWithClock(backends, chrono.System)
+}
 
-	path, err := extractToTemp(fs, filename)
+// NewExecPlayerWithClock creates an idle ExecPlayer, as NewExecPlayer does,
+// that reads the time from c.
+func NewExecPlayerWithClock(backends []Backend, c chrono.Clock) *ExecPlayer {
+	if backends == nil {
+		backends = DefaultBackends()
+	}
+	removeStaleTemps()
+	return &ExecPlayer{
+		events:   make(chan Event, eventBuffer),
+		backends: backends,
+		volume:   MaxVolume,
+		sounds:   make(map[string]*sound),
+		clock:    c,
+	}
+}
+
+// SetFades sets how long sounds take to fade in when they start and to fade
+// out when stopped. Fades apply where the backend allows live volume changes.
+func (p *ExecPlayer) SetFades(in, out time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fadeIn, p.fadeOut = in, out
+}
+
+// load returns the named sound, read from fsys each time so that a changed
+// file, or another filesystem's sound of the same name, is what plays. What
+// was made from the sound is kept while its bytes stay the same. p.mu must
+// be held.
+func (p *ExecPlayer) load(fsys fs.FS, name string) (*sound, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("read embedded file: %w", err)
+	}
+	if snd, ok := p.sounds[name]; ok {
+		if bytes.Equal(snd.data, data) {
+			return snd, nil
+		}
+		if snd.path != "" {
+			os.Remove(snd.path)
+		}
+	}
+	snd := &sound{name: name, format: DetectFormat(data, name), data: data}
+	p.sounds[name] = snd
+	return snd, nil
+}
+
+// tempPrefix starts the name of every temp file a player creates. The ID of
+// the process that made it follows.
+const tempPrefix = "azan_clock-"
+
+// writeTemp copies data into a new temp file named after the sound, that
+// only the current user can read. The file is positioned at its start.
+func writeTemp(name, format string, data []byte) (*os.File, error) {
+	stem := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	f, err := os.CreateTemp("", fmt.Sprintf("%s%d-%s-*.%s", tempPrefix, os.Getpid(), stem, format))
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("write temp file: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("rewind temp file: %w", err)
+	}
+	return f, nil
+}
+
+// Play implements Player.
+func (p *ExecPlayer) Play(ctx context.Context, fsys fs.FS, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == Playing {
+		return ErrBusy
+	}
+
+	snd, err := p.load(fsys, name)
 	if err != nil {
-		mu.Lock()
-		playing = false
-		mu.Unlock()
 		return err
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	snd.duration()
+	p.state = Playing
+	p.cancel = cancel
+	p.done = done
+	p.current = snd
+	p.start = p.clock.Now()
+	p.emit(Event{Kind: EventStarted, Sound: name})
+
+	pb := &playback{
+		snd:      snd,
+		backends: p.backends,
+		volume:   p.volume,
+		fadeIn:   p.fadeIn,
+		started: func(proc *os.Process) {
+			p.mu.Lock()
+			p.proc = proc
+			p.mu.Unlock()
+		},
+		control: func(c volumeControl) {
+			p.mu.Lock()
+			p.control = c
+			p.mu.Unlock()
+		},
+		mu: &p.mu,
+	}
+
 	go func() {
-		defer func() {
-			mu.Lock()
-			playing = false
-			mu.Unlock()
-		}()
-		playFile(path)
+		err := playFile(ctx, pb)
+		stopped := ctx.Err() != nil
+		cancel()
+
+		p.mu.Lock()
+		p.state = Idle
+		p.cancel = nil
+		p.done = nil
+		p.proc = nil
+		p.control = nil
+		p.current = nil
+		p.mu.Unlock()
+		close(done)
+
+		switch {
+		case stopped:
+			p.emit(Event{Kind: EventStopped, Sound: name})
+		case err != nil:
+			p.emit(Event{Kind: EventFailed, Sound: name, Err: err})
+		default:
+			p.emit(Event{Kind: EventFinished, Sound: name})
+		}
 	}()
 
 	return nil
 }
 
-// Stop stops any currently playing audio.
-func Stop() {
-	mu.Lock()
-	defer mu.Unlock()
-	if !playing {
+// Stop implements Player. The sound fades out first if the backend allows
+// it. On Unix the player's process group then gets SIGTERM, and SIGKILL if it
+// has not exited within a grace period; Stop returns once the process has
+// been reaped.
+func (p *ExecPlayer) Stop() {
+	p.mu.Lock()
+	cancel, done, control, fadeOut := p.cancel, p.done, p.control, p.fadeOut
+	p.mu.Unlock()
+	if cancel == nil {
 		return
 	}
-	stopPlayback()
-	playing = false
+	if control != nil && fadeOut > 0 {
+		select {
+		case <-control.FadeOut(fadeOut):
+		case <-done:
+		case <-time.After(fadeOut + time.Second):
+		}
+	}
+	cancel()
+	<-done
 }
 
-// IsPlaying returns whether audio is currently playing.
-func IsPlaying() bool {
-	mu.Lock()
-	defer mu.Unlock()
-	return playing
+// SetVolume implements Player.
+func (p *ExecPlayer) SetVolume(volume int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.volume = clampVolume(volume)
+	if p.control != nil {
+		p.control.SetVolume(p.volume)
+	}
 }
 
-// Cleanup removes the temp file.
-func Cleanup() {
-	if tempFile != "" {
-		os.Remove(tempFile)
+// Volume implements Player.
+func (p *ExecPlayer) Volume() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.volume
+}
+
+// State implements Player.
+func (p *ExecPlayer) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// Progress implements Player. The position is the time since the sound
+// started.
+func (p *ExecPlayer) Progress() Progress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current == nil {
+		return Progress{}
 	}
+	return progressAt(p.current.name, p.start, p.clock.Now(), p.current.duration())
 }
 
-func playFile(path string) {
-	switch runtime.GOOS {
-	case "windows":
-		playWindows(path)
-	case "darwin":
-		cmd := exec.Command("afplay", path)
-		cmd.Run()
-	default:
-		// Try common Linux players
-		for _, player := range []string{"mpv", "ffplay", "aplay", "paplay"} {
-			if p, err := exec.LookPath(player); err == nil {
-				var cmd *exec.Cmd
-				if player == "ffplay" {
-					cmd = exec.Command(p, "-nodisp", "-autoexit", path)
-				} else {
-					cmd = exec.Command(p, path)
-				}
-				cmd.Run()
-				return
-			}
+// Pid returns the process ID of the running player, or 0 if there is none.
+func (p *ExecPlayer) Pid() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.proc == nil {
+		return 0
+	}
+	return p.proc.Pid
+}
+
+// Events implements Player.
+func (p *ExecPlayer) Events() <-chan Event {
+	return p.events
+}
+
+// Close stops playback and removes any temp files.
+func (p *ExecPlayer) Close() error {
+	p.Stop()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var firstErr error
+	for _, snd := range p.sounds {
+		if snd.path == "" {
+			continue
+		}
+		if err := os.Remove(snd.path); err != nil && firstErr == nil {
+			firstErr = err
 		}
+		snd.path = ""
+	}
+	return firstErr
+}
+
+func (p *ExecPlayer) emit(e Event) {
+	e.Time = p.clock.Now()
+	sendEvent(p.events, e)
+}
+
+// sendEvent stamps e and delivers it without blocking.
+func sendEvent(ch chan Event, e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	select {
+	case ch <- e:
+	default:
 	}
 }
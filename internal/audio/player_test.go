@@ -0,0 +1,143 @@
+package audio
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testFS = fstest.MapFS{
+	"azan.mp3": {Data: []byte("not really an mp3")},
+}
+
+// nextEvent waits for the next event on ch.
+func nextEvent(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestFakePlayer_PlayAndFinish(t *testing.T) {
+	p := NewFakePlayer()
+
+	if err := p.Play(context.Background(), testFS, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	if p.State() != Playing {
+		t.Errorf("expected Playing, got %v", p.State())
+	}
+	if err := p.Play(context.Background(), testFS, "azan.mp3"); err != ErrBusy {
+		t.Errorf("expected ErrBusy while playing, got %v", err)
+	}
+
+	p.Finish()
+	if p.State() != Idle {
+		t.Errorf("expected Idle after Finish, got %v", p.State())
+	}
+
+	if e := nextEvent(t, p.Events()); e.Kind != EventStarted || e.Sound != "azan.mp3" {
+		t.Errorf("expected started event for azan.mp3, got %v %q", e.Kind, e.Sound)
+	}
+	if e := nextEvent(t, p.Events()); e.Kind != EventFinished {
+		t.Errorf("expected finished event, got %v", e.Kind)
+	}
+	if got := p.Played(); len(got) != 1 || got[0] != "azan.mp3" {
+		t.Errorf("expected one recorded play, got %v", got)
+	}
+}
+
+func TestFakePlayer_Progress(t *testing.T) {
+	p := NewFakePlayer()
+	p.SetDuration(time.Hour)
+	if got := p.Progress(); got != (Progress{}) {
+		t.Errorf("expected zero Progress when idle, got %+v", got)
+	}
+
+	p.Play(context.Background(), testFS, "azan.mp3")
+	got := p.Progress()
+	if got.Sound != "azan.mp3" || got.Duration != time.Hour {
+		t.Errorf("unexpected progress %+v", got)
+	}
+	if f := got.Fraction(); f < 0 || f > 0.01 {
+		t.Errorf("expected playback to have just started, got fraction %v", f)
+	}
+	p.Stop()
+	if got := p.Progress(); got != (Progress{}) {
+		t.Errorf("expected zero Progress after Stop, got %+v", got)
+	}
+}
+
+func TestProgress_Fraction(t *testing.T) {
+	start := time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)
+	tests := []struct {
+		elapsed  time.Duration
+		duration time.Duration
+		want     float64
+	}{
+		{30 * time.Second, time.Minute, 0.5},
+		{2 * time.Minute, time.Minute, 1},
+		{30 * time.Second, 0, 0},
+	}
+	for _, tc := range tests {
+		p := progressAt("azan.mp3", start, start.Add(tc.elapsed), tc.duration)
+		if got := p.Fraction(); got != tc.want {
+			t.Errorf("%v of %v: fraction %v, want %v", tc.elapsed, tc.duration, got, tc.want)
+		}
+	}
+}
+
+func TestFakePlayer_ContextCancelStops(t *testing.T) {
+	p := NewFakePlayer()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if err := p.Play(ctx, testFS, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	nextEvent(t, p.Events())
+
+	cancel()
+	if e := nextEvent(t, p.Events()); e.Kind != EventStopped {
+		t.Errorf("expected stopped event, got %v", e.Kind)
+	}
+	if p.State() != Idle {
+		t.Errorf("expected Idle after cancel, got %v", p.State())
+	}
+}
+
+func TestExecPlayer_NoPlayerFails(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	p := NewExecPlayer(nil)
+	defer p.Close()
+
+	if err := p.Play(context.Background(), testFS, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	if e := nextEvent(t, p.Events()); e.Kind != EventStarted {
+		t.Errorf("expected started event, got %v", e.Kind)
+	}
+	e := nextEvent(t, p.Events())
+	if e.Kind != EventFailed || e.Err == nil {
+		t.Errorf("expected failed event with error, got %v (%v)", e.Kind, e.Err)
+	}
+	if p.State() != Idle {
+		t.Errorf("expected Idle after failure, got %v", p.State())
+	}
+}
+
+func TestExecPlayer_MissingSound(t *testing.T) {
+	p := NewExecPlayer(nil)
+	defer p.Close()
+
+	if err := p.Play(context.Background(), testFS, "missing.mp3"); err == nil {
+		t.Error("expected error for missing sound, got nil")
+	}
+	if p.State() != Idle {
+		t.Errorf("expected Idle after error, got %v", p.State())
+	}
+}
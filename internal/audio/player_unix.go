@@ -3,16 +3,176 @@
 package audio
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
 	"os/exec"
+	"runtime"
 	"syscall"
+	"time"
 )
 
-var currentCmd *exec.Cmd
+// stopTimeout is how long a player may take to exit after SIGTERM before
+// its process group is killed.
+var stopTimeout = 2 * time.Second
 
-func stopPlayback() {
-	if currentCmd != nil && currentCmd.Process != nil {
-		// Kill the process group
-		syscall.Kill(-currentCmd.Process.Pid, syscall.SIGKILL)
-		currentCmd = nil
+// feedLead is how far ahead of real time raw PCM is written to a player.
+var feedLead = 250 * time.Millisecond
+
+// DefaultBackends returns the players tried when none are configured: afplay
+// on macOS, and mpv, ffplay, aplay and paplay elsewhere. The latter are all
+// fed raw PCM decoded in process, so that volume and fades work live.
+func DefaultBackends() []Backend {
+	if runtime.GOOS == "darwin" {
+		return []Backend{
+			{Name: "afplay", Command: []string{"afplay", "-v", GainPlaceholder, FilePlaceholder}, Formats: []string{FormatMP3, FormatWAV}},
+		}
+	}
+	return []Backend{
+		{Name: "mpv", Command: []string{"mpv", "--no-terminal", "--demuxer=rawaudio",
+			"--demuxer-rawaudio-format=s16le", "--demuxer-rawaudio-rate=" + RatePlaceholder,
+			"--demuxer-rawaudio-channels=" + ChannelsPlaceholder, "-"}, Formats: []string{FormatPCM}},
+		{Name: "ffplay", Command: []string{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet",
+			"-f", "s16le", "-ar", RatePlaceholder, "-ac", ChannelsPlaceholder, "-i", "pipe:"}, Formats: []string{FormatPCM}},
+		{Name: "aplay", Command: []string{"aplay", "-q", "-t", "raw", "-f", "S16_LE",
+			"-c", ChannelsPlaceholder, "-r", RatePlaceholder}, Formats: []string{FormatPCM}},
+		{Name: "paplay", Command: []string{"paplay", "--raw", "--format=s16le",
+			"--channels=" + ChannelsPlaceholder, "--rate=" + RatePlaceholder}, Formats: []string{FormatPCM}},
+	}
+}
+
+// fdInput is the path at which a child sees its first extra file.
+const fdInput = "/dev/fd/3"
+
+// playerCmd is a player command and how to hand it the sound.
+type playerCmd struct {
+	*exec.Cmd
+	name    string                          // backend name, for errors
+	feed    func(ctx context.Context) error // writes the sound once started, if set
+	cleanup func()                          // called once the command has started
+}
+
+// playerCommand builds the command that plays pb with the first suitable
+// backend.
+func playerCommand(pb *playback) (*playerCmd, error) {
+	snd := pb.snd
+	choice, err := chooseBackend(probeBackends(pb.backends), snd.format)
+	if err != nil {
+		return nil, err
+	}
+	b := choice.Backend
+
+	switch choice.Format {
+	case FormatPCM:
+		st, err := snd.stream()
+		if err != nil {
+			return nil, err
+		}
+		cmd := exec.Command(choice.Path, b.args("", st, MaxVolume)...)
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		fader := NewFader(&pacedSink{next: NewPipeSink(stdin), lead: feedLead}, pb.volume, pb.fadeIn)
+		fader.tail = feedLead
+		pb.control(fader)
+		return &playerCmd{
+			Cmd:  cmd,
+			name: b.Name,
+			feed: func(ctx context.Context) error {
+				// A write fails once the player exits, which ends decoding.
+				return snd.decode(ctx, fader)
+			},
+			cleanup: func() {},
+		}, nil
+	case FormatWAV:
+		if snd.format != FormatWAV {
+			data, err := snd.asWAV()
+			if err != nil {
+				return nil, err
+			}
+			return dataCommand(choice, snd.name, FormatWAV, data, pb.volume)
+		}
+	}
+	return dataCommand(choice, snd.name, snd.format, snd.data, pb.volume)
+}
+
+// dataCommand builds a command that is given data on standard input, or as
+// an already-unlinked temp file so that nothing is left behind on disk
+// however the process ends.
+func dataCommand(choice Choice, name, format string, data []byte, volume int) (*playerCmd, error) {
+	b := choice.Backend
+	if b.Stdin() {
+		cmd := exec.Command(choice.Path, b.args("", Stream{}, volume)...)
+		cmd.Stdin = bytes.NewReader(data)
+		return &playerCmd{Cmd: cmd, name: b.Name, cleanup: func() {}}, nil
+	}
+
+	f, err := writeTemp(name, format, data)
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(f.Name())
+	cmd := exec.Command(choice.Path, b.args(fdInput, Stream{}, volume)...)
+	cmd.ExtraFiles = []*os.File{f}
+	return &playerCmd{Cmd: cmd, name: b.Name, cleanup: func() { f.Close() }}, nil
+}
+
+// removeStaleTemps is a no-op on Unix, where temp files are unlinked as soon
+// as they are opened.
+func removeStaleTemps() {}
+
+// playFile plays pb with the first suitable backend and blocks until the
+// player exits or ctx is cancelled. The player is always reaped before
+// playFile returns.
+func playFile(ctx context.Context, pb *playback) error {
+	cmd, err := playerCommand(pb)
+	if err != nil {
+		return err
+	}
+	// Give the player its own process group so a stop also reaches any
+	// decoders or output helpers it spawns.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	err = cmd.Start()
+	cmd.cleanup()
+	if err != nil {
+		return fmt.Errorf("start %s: %w", cmd.name, err)
+	}
+	pb.started(cmd.Process)
+	fed := make(chan error, 1)
+	if cmd.feed != nil {
+		go func() { fed <- cmd.feed(ctx) }()
+	} else {
+		fed <- nil
+	}
+
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- cmd.Wait() }()
+
+	select {
+	case err := <-waitErr:
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.name, err)
+		}
+		// A player given a sound that stopped decoding part way still
+		// exits cleanly once its input ends.
+		if err := <-fed; err != nil && ctx.Err() == nil {
+			return fmt.Errorf("play %s with %s: %w", pb.snd.name, cmd.name, err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	pgid := cmd.Process.Pid
+	syscall.Kill(-pgid, syscall.SIGTERM)
+	select {
+	case <-waitErr:
+	case <-time.After(stopTimeout):
+		syscall.Kill(-pgid, syscall.SIGKILL)
+		<-waitErr
 	}
+	// Children that ignored SIGTERM can outlive the leader.
+	syscall.Kill(-pgid, syscall.SIGKILL)
+	return ctx.Err()
 }
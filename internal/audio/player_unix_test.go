@@ -0,0 +1,424 @@
+//go:build linux
+
+package audio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+)
+
+// installFakePlayer makes PATH contain only an executable shell script with
+// the given name and body, plus the few tools the scripts use. It returns the
+// directory the script lives in.
+func installFakePlayer(t *testing.T, name, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, tool := range []string{"cat", "dirname", "sleep"} {
+		p, err := exec.LookPath(tool)
+		if err != nil {
+			t.Skipf("%s not available: %v", tool, err)
+		}
+		if err := os.Symlink(p, filepath.Join(dir, tool)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	script := "#!/bin/sh\ndir=$(dirname \"$0\")\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return dir
+}
+
+// privateTempDir points os.TempDir at a fresh directory for the test.
+func privateTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	return dir
+}
+
+// waitForPid polls for a PID written by the fake player.
+func waitForPid(t *testing.T, path string) int {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		data, err := os.ReadFile(path)
+		if err == nil && strings.HasSuffix(string(data), "\n") {
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				t.Fatalf("bad pid file %s: %q", path, data)
+			}
+			return pid
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", path)
+	return 0
+}
+
+// alive reports whether pid exists and is not a zombie.
+func alive(pid int) bool {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	// The state follows the parenthesised command name.
+	stat := string(data)
+	i := strings.LastIndexByte(stat, ')')
+	return i >= 0 && i+2 < len(stat) && stat[i+2] != 'Z'
+}
+
+// waitDead polls until pid has gone away.
+func waitDead(t *testing.T, pid int, what string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for alive(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s (pid %d) still running", what, pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// stdinMP3 is a backend that takes the MP3 itself on stdin, so fake players
+// see the test bytes unchanged.
+func stdinMP3(t *testing.T) []Backend {
+	t.Helper()
+	b, err := ParseBackend("mpv -", []string{FormatMP3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []Backend{b}
+}
+
+func TestExecPlayer_StopKillsProcessGroup(t *testing.T) {
+	dir := installFakePlayer(t, "mpv", `sleep 30 &
+echo $! > "$dir/child"
+wait`)
+	p := NewExecPlayer(stdinMP3(t))
+	defer p.Close()
+
+	if err := p.Play(context.Background(), testFS, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	child := waitForPid(t, filepath.Join(dir, "child"))
+	leader := p.Pid()
+	if leader == 0 {
+		t.Fatal("expected a tracked player pid while playing")
+	}
+
+	p.Stop()
+
+	if p.State() != Idle {
+		t.Errorf("expected Idle after Stop, got %v", p.State())
+	}
+	if p.Pid() != 0 {
+		t.Errorf("expected no tracked pid after Stop, got %d", p.Pid())
+	}
+	if alive(leader) {
+		t.Errorf("player (pid %d) still running after Stop", leader)
+	}
+	waitDead(t, child, "player child")
+
+	nextEvent(t, p.Events())
+	if e := nextEvent(t, p.Events()); e.Kind != EventStopped {
+		t.Errorf("expected stopped event, got %v", e.Kind)
+	}
+}
+
+func TestExecPlayer_StopEscalatesToKill(t *testing.T) {
+	old := stopTimeout
+	stopTimeout = 100 * time.Millisecond
+	defer func() { stopTimeout = old }()
+
+	dir := installFakePlayer(t, "mpv", `trap '' TERM
+sleep 30 &
+echo $! > "$dir/child"
+wait`)
+	p := NewExecPlayer(stdinMP3(t))
+	defer p.Close()
+
+	if err := p.Play(context.Background(), testFS, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	child := waitForPid(t, filepath.Join(dir, "child"))
+	leader := p.Pid()
+
+	start := time.Now()
+	p.Stop()
+	if elapsed := time.Since(start); elapsed < stopTimeout {
+		t.Errorf("Stop returned after %v, before the SIGTERM grace period", elapsed)
+	}
+	if alive(leader) {
+		t.Errorf("player (pid %d) survived SIGKILL", leader)
+	}
+	waitDead(t, child, "player child")
+}
+
+func TestExecPlayer_ExitStatus(t *testing.T) {
+	tests := []struct {
+		body string
+		want EventKind
+	}{
+		{"exit 0", EventFinished},
+		{"exit 3", EventFailed},
+	}
+
+	for _, tc := range tests {
+		installFakePlayer(t, "mpv", tc.body)
+		p := NewExecPlayer(stdinMP3(t))
+
+		if err := p.Play(context.Background(), testFS, "azan.mp3"); err != nil {
+			t.Fatalf("Play failed: %v", err)
+		}
+		nextEvent(t, p.Events())
+		e := nextEvent(t, p.Events())
+		if e.Kind != tc.want {
+			t.Errorf("%q: expected %v event, got %v (%v)", tc.body, tc.want, e.Kind, e.Err)
+		}
+		if e.Kind == EventFailed && (e.Err == nil || e.Err.Error() != "mpv: exit status 3") {
+			t.Errorf("%q: expected the failure to name the player, got %v", tc.body, e.Err)
+		}
+		if p.State() != Idle {
+			t.Errorf("%q: expected Idle after exit, got %v", tc.body, p.State())
+		}
+		p.Close()
+	}
+}
+
+func TestExecPlayer_StreamsToStdin(t *testing.T) {
+	tmp := privateTempDir(t)
+	dir := installFakePlayer(t, "mpv", `[ "$1" = "-" ] || exit 2
+cat > "$dir/got"`)
+	p := NewExecPlayer(stdinMP3(t))
+	defer p.Close()
+
+	if err := p.Play(context.Background(), testFS, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	nextEvent(t, p.Events())
+	if e := nextEvent(t, p.Events()); e.Kind != EventFinished {
+		t.Fatalf("expected finished event, got %v (%v)", e.Kind, e.Err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "got"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, testFS["azan.mp3"].Data) {
+		t.Errorf("player read %q, want %q", got, testFS["azan.mp3"].Data)
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("expected no temp files when streaming, found %d", len(entries))
+	}
+}
+
+func TestExecPlayer_ProgressByClock(t *testing.T) {
+	installFakePlayer(t, "mpv", `cat > /dev/null
+sleep 5`)
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	clk := chrono.NewFake(start)
+	p := NewExecPlayerWithClock(stdinMP3(t), clk)
+	defer p.Close()
+
+	fsys := fstest.MapFS{"azan.mp3": {Data: readShortMP3(t)}}
+	if err := p.Play(context.Background(), fsys, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	if e := nextEvent(t, p.Events()); e.Kind != EventStarted || !e.Time.Equal(start) {
+		t.Errorf("expected a start event at %v, got %v at %v", start, e.Kind, e.Time)
+	}
+	clk.Advance(time.Second)
+	if got := p.Progress(); got.Position != time.Second {
+		t.Errorf("expected to be a second in by the clock, got %+v", got)
+	}
+}
+
+func TestExecPlayer_SameNameFromTwoFilesystems(t *testing.T) {
+	dir := installFakePlayer(t, "mpv", `cat > "$dir/got"`)
+	p := NewExecPlayer(stdinMP3(t))
+	defer p.Close()
+
+	builtin := fstest.MapFS{"beep.mp3": {Data: []byte("built-in beep")}}
+	mine := fstest.MapFS{"beep.mp3": {Data: []byte("my own beep")}}
+	for _, fsys := range []fstest.MapFS{builtin, mine, builtin} {
+		if err := p.Play(context.Background(), fsys, "beep.mp3"); err != nil {
+			t.Fatalf("Play failed: %v", err)
+		}
+		nextEvent(t, p.Events())
+		if e := nextEvent(t, p.Events()); e.Kind != EventFinished {
+			t.Fatalf("expected finished event, got %v (%v)", e.Kind, e.Err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "got"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fsys["beep.mp3"].Data; !bytes.Equal(got, want) {
+			t.Errorf("player read %q, want %q", got, want)
+		}
+	}
+}
+
+func TestExecPlayer_FileBackendUsesUnlinkedTemp(t *testing.T) {
+	tmp := privateTempDir(t)
+	dir := installFakePlayer(t, "fakeplay", `cat "$1" > "$dir/got-$(cat "$1")"`)
+	backend, err := ParseBackend("fakeplay {file}", []string{FormatMP3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"azan.mp3": {Data: []byte("first")},
+		"dua.mp3":  {Data: []byte("second")},
+	}
+	p := NewExecPlayer([]Backend{backend})
+	defer p.Close()
+
+	for _, name := range []string{"azan.mp3", "dua.mp3", "azan.mp3"} {
+		if err := p.Play(context.Background(), fsys, name); err != nil {
+			t.Fatalf("Play(%s) failed: %v", name, err)
+		}
+		nextEvent(t, p.Events())
+		if e := nextEvent(t, p.Events()); e.Kind != EventFinished {
+			t.Fatalf("%s: expected finished event, got %v (%v)", name, e.Kind, e.Err)
+		}
+		if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+			t.Errorf("%s: expected temp file to be unlinked, found %d entries", name, len(entries))
+		}
+	}
+
+	for _, want := range []string{"first", "second"} {
+		got, err := os.ReadFile(filepath.Join(dir, "got-"+want))
+		if err != nil {
+			t.Errorf("player never read %q: %v", want, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("player read %q, want %q", got, want)
+		}
+	}
+}
+
+func TestExecPlayer_StreamsPCMToPCMOnlyPlayer(t *testing.T) {
+	dir := installFakePlayer(t, "aplay", `echo "$@" > "$dir/args"
+cat > "$dir/got"`)
+	p := NewExecPlayer(nil)
+	defer p.Close()
+
+	fsys := fstest.MapFS{"azan.mp3": {Data: readShortMP3(t)}}
+	if err := p.Play(context.Background(), fsys, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	nextEvent(t, p.Events())
+	if e := nextEvent(t, p.Events()); e.Kind != EventFinished {
+		t.Fatalf("expected finished event, got %v (%v)", e.Kind, e.Err)
+	}
+
+	args, err := os.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(args), "-c 2 -r 22050") {
+		t.Errorf("expected aplay to be told the PCM format, got %q", args)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "got"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(got)
+	if hex.EncodeToString(sum[:]) != shortPCMSHA256 {
+		t.Errorf("aplay was not given the decoded PCM (%d bytes)", len(got))
+	}
+}
+
+func TestExecPlayer_UnfedSoundFails(t *testing.T) {
+	// The player stops reading its input but still exits cleanly.
+	installFakePlayer(t, "aplay", `exec 0<&-
+sleep 0.5`)
+	p := NewExecPlayer(nil)
+	defer p.Close()
+
+	fsys := fstest.MapFS{"azan.mp3": {Data: readShortMP3(t)}}
+	if err := p.Play(context.Background(), fsys, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	nextEvent(t, p.Events())
+	if e := nextEvent(t, p.Events()); e.Kind != EventFailed {
+		t.Fatalf("expected failed event, got %v", e.Kind)
+	}
+}
+
+func TestExecPlayer_ConvertsMP3ForWAVOnlyPlayer(t *testing.T) {
+	dir := installFakePlayer(t, "fakeplay", `cat "$1" > "$dir/got"`)
+	backend, err := ParseBackend("fakeplay {file}", []string{FormatWAV})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewExecPlayer([]Backend{backend})
+	defer p.Close()
+
+	fsys := fstest.MapFS{"azan.mp3": {Data: readShortMP3(t)}}
+	if err := p.Play(context.Background(), fsys, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	nextEvent(t, p.Events())
+	if e := nextEvent(t, p.Events()); e.Kind != EventFinished {
+		t.Fatalf("expected finished event, got %v (%v)", e.Kind, e.Err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "got"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DetectFormat(got, "") != FormatWAV || len(got) != wavHeaderSize+shortPCMSize {
+		t.Errorf("expected %d bytes of WAV, got %d", wavHeaderSize+shortPCMSize, len(got))
+	}
+}
+
+func TestExecPlayer_StopFadesOut(t *testing.T) {
+	dir := installFakePlayer(t, "aplay", `cat > "$dir/got"`)
+	p := NewExecPlayer(nil)
+	p.SetFades(0, 100*time.Millisecond)
+	defer p.Close()
+
+	fsys := fstest.MapFS{"azan.mp3": {Data: readShortMP3(t)}}
+	if err := p.Play(context.Background(), fsys, "azan.mp3"); err != nil {
+		t.Fatalf("Play failed: %v", err)
+	}
+	nextEvent(t, p.Events())
+	time.Sleep(300 * time.Millisecond)
+
+	start := time.Now()
+	p.Stop()
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Stop returned after %v, before the fade-out", elapsed)
+	}
+	if e := nextEvent(t, p.Events()); e.Kind != EventStopped {
+		t.Fatalf("expected stopped event, got %v (%v)", e.Kind, e.Err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "got"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) >= shortPCMSize {
+		t.Fatalf("expected playback to be cut short, player read all %d bytes", len(got))
+	}
+	// The player should have been fed the silent tail before being stopped.
+	tail := got[len(got)-int(Stream{SampleRate: 22050, Channels: 2}.BytesPerSecond()/10):]
+	if !bytes.Equal(tail, make([]byte, len(tail))) {
+		t.Error("expected audio to end in silence after a fade-out")
+	}
+}
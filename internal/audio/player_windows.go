@@ -3,8 +3,15 @@
 package audio
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -25,22 +32,135 @@ func mciSend(cmd string) error {
 	return nil
 }
 
-func playWindows(path string) {
+// removeStaleTemps deletes temp files left behind by clocks that did not
+// exit cleanly. The files of clocks still running are kept.
+func removeStaleTemps() {
+	matches, _ := filepath.Glob(filepath.Join(os.TempDir(), tempPrefix+"*"))
+	for _, m := range matches {
+		if pid, ok := tempOwner(filepath.Base(m)); ok && !running(pid) {
+			os.Remove(m)
+		}
+	}
+}
+
+// tempOwner returns the ID of the process that made the temp file name.
+func tempOwner(name string) (int, bool) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(name, tempPrefix), "-")
+	pid, err := strconv.Atoi(id)
+	return pid, ok && err == nil
+}
+
+// running reports whether the process pid is still running.
+func running(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
+
+// DefaultBackends returns the built-in MCI player, which handles MP3 and WAV.
+func DefaultBackends() []Backend {
+	return []Backend{{Name: "mci", Formats: []string{FormatMP3, FormatWAV}}}
+}
+
+// mciFadeStep is how often MCI fades adjust the volume.
+const mciFadeStep = 50 * time.Millisecond
+
+// mciControl adjusts the volume of the playing "azan" alias. MCI volumes run
+// from 0 to 1000.
+type mciControl struct {
+	mu     sync.Mutex
+	volume int
+	env    float64 // fade envelope, 0 to 1
+	out    chan struct{}
+}
+
+// apply sends the current volume to MCI. m.mu must be held.
+func (m *mciControl) apply() {
+	mciSend(fmt.Sprintf("setaudio azan volume to %d", int(gainFor(m.volume)*m.env*1000)))
+}
+
+// SetVolume implements volumeControl.
+func (m *mciControl) SetVolume(volume int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.volume = volume
+	m.apply()
+}
+
+// ramp moves the envelope from one level to another over d.
+func (m *mciControl) ramp(from, to float64, d time.Duration) {
+	steps := max(1, int(d/mciFadeStep))
+	for i := 1; i <= steps; i++ {
+		m.mu.Lock()
+		m.env = from + (to-from)*float64(i)/float64(steps)
+		m.apply()
+		m.mu.Unlock()
+		time.Sleep(mciFadeStep)
+	}
+}
+
+// FadeOut implements volumeControl.
+func (m *mciControl) FadeOut(d time.Duration) <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.out == nil {
+		m.out = make(chan struct{})
+		go func(from float64) {
+			m.ramp(from, 0, d)
+			close(m.out)
+		}(m.env)
+	}
+	return m.out
+}
+
+// playFile plays pb through MCI and blocks until it ends or ctx is
+// cancelled. MCI needs a named file, so the sound is copied to a private
+// temp file the first time it is played. MCI plays in-process, so backends
+// are ignored and no process is reported as started.
+func playFile(ctx context.Context, pb *playback) error {
+	snd := pb.snd
+	pb.mu.Lock()
+	if snd.path == "" {
+		f, err := writeTemp(snd.name, snd.format, snd.data)
+		if err != nil {
+			pb.mu.Unlock()
+			return err
+		}
+		f.Close()
+		snd.path = f.Name()
+	}
+	path := snd.path
+	pb.mu.Unlock()
+
 	// Close any previous instance
 	mciSend("close azan")
 
 	openCmd := fmt.Sprintf(`open "%s" type mpegvideo alias azan`, path)
 	if err := mciSend(openCmd); err != nil {
-		return
+		return err
 	}
-	if err := mciSend("play azan wait"); err != nil {
-		mciSend("close azan")
-		return
+	defer mciSend("close azan")
+
+	ctl := &mciControl{volume: pb.volume, env: 1}
+	if pb.fadeIn > 0 {
+		ctl.env = 0
+	}
+	ctl.mu.Lock()
+	ctl.apply()
+	ctl.mu.Unlock()
+	pb.control(ctl)
+	if pb.fadeIn > 0 {
+		go ctl.ramp(0, 1, pb.fadeIn)
 	}
-	mciSend("close azan")
-}
 
-func stopPlayback() {
-	mciSend("stop azan")
-	mciSend("close azan")
+	// "play ... wait" returns early once the alias is stopped.
+	stop := context.AfterFunc(ctx, func() { mciSend("stop azan") })
+	defer stop()
+	if err := mciSend("play azan wait"); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return ctx.Err()
 }
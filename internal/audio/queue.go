@@ -0,0 +1,229 @@
+package audio
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// Step is one part of a Sequence: a sound, or a pause of Gap when Sound is
+// empty.
+type Step struct {
+	Sound string
+	Gap   time.Duration
+}
+
+// Sequence is a list of sounds and pauses played in order, such as the
+// adhan, three seconds of silence and then the dua.
+type Sequence []Step
+
+// queued is a sequence waiting to be played.
+type queued struct {
+	ctx  context.Context
+	fsys fs.FS
+	seq  Sequence
+}
+
+// Queue is a Player that plays sequences through another Player, one after
+// another. Sounds and sequences requested while something is playing wait
+// their turn instead of failing with ErrBusy. Events of the underlying
+// player are passed on, so it must not be read from directly.
+type Queue struct {
+	player Player
+	events chan Event
+
+	mu        sync.Mutex
+	pending   []queued
+	running   bool
+	stopping  bool               // Stop was called for the current sequence
+	cancel    context.CancelFunc // cancels the current sequence
+	done      chan struct{}      // closed when the current run ends
+	waiting   chan Event         // receives the end of the current sound
+	gapStart  time.Time
+	gap       time.Duration // length of the current pause, if in one
+	volumeFor func(sound string) int
+}
+
+// NewQueue creates a Queue playing through player.
+func NewQueue(player Player) *Queue {
+	q := &Queue{player: player, events: make(chan Event, eventBuffer)}
+	go q.forward()
+	return q
+}
+
+// SetVolumeFor makes the queue set the volume from f before each sound.
+func (q *Queue) SetVolumeFor(f func(sound string) int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.volumeFor = f
+}
+
+// forward passes on the player's events, and tells the running sequence
+// when its sound has ended.
+func (q *Queue) forward() {
+	for e := range q.player.Events() {
+		if e.Kind != EventStarted {
+			q.mu.Lock()
+			w := q.waiting
+			q.waiting = nil
+			q.mu.Unlock()
+			if w != nil {
+				w <- e
+			}
+		}
+		sendEvent(q.events, e)
+	}
+}
+
+// Play implements Player by queueing a sequence of one sound. It never
+// returns ErrBusy.
+func (q *Queue) Play(ctx context.Context, fsys fs.FS, name string) error {
+	return q.PlaySequence(ctx, fsys, Sequence{{Sound: name}})
+}
+
+// PlaySequence queues seq and starts playing it once everything queued
+// before it has finished.
+func (q *Queue) PlaySequence(ctx context.Context, fsys fs.FS, seq Sequence) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, queued{ctx: ctx, fsys: fsys, seq: seq})
+	if !q.running {
+		q.running = true
+		q.done = make(chan struct{})
+		go q.run(q.done)
+	}
+	return nil
+}
+
+// run plays queued sequences until none are left.
+func (q *Queue) run(done chan struct{}) {
+	defer close(done)
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.running = false
+			q.cancel = nil
+			q.mu.Unlock()
+			return
+		}
+		next := q.pending[0]
+		q.pending = q.pending[1:]
+		ctx, cancel := context.WithCancel(next.ctx)
+		q.cancel = cancel
+		q.stopping = false
+		q.mu.Unlock()
+
+		q.playSequence(ctx, next.fsys, next.seq)
+		cancel()
+	}
+}
+
+// playSequence plays the steps of seq until they are done or the sequence
+// is stopped. A sound that fails to start is reported and skipped.
+func (q *Queue) playSequence(ctx context.Context, fsys fs.FS, seq Sequence) {
+	for _, step := range seq {
+		q.mu.Lock()
+		stop := q.stopping || ctx.Err() != nil
+		volumeFor := q.volumeFor
+		q.mu.Unlock()
+		if stop {
+			return
+		}
+
+		if step.Sound == "" {
+			q.pause(ctx, step.Gap)
+			continue
+		}
+
+		if volumeFor != nil {
+			q.player.SetVolume(volumeFor(step.Sound))
+		}
+		ended := make(chan Event, 1)
+		q.mu.Lock()
+		q.waiting = ended
+		q.mu.Unlock()
+		if err := q.player.Play(ctx, fsys, step.Sound); err != nil {
+			q.mu.Lock()
+			q.waiting = nil
+			q.mu.Unlock()
+			sendEvent(q.events, Event{Kind: EventFailed, Sound: step.Sound, Err: err})
+			continue
+		}
+		if e := <-ended; e.Kind == EventStopped {
+			return
+		}
+	}
+}
+
+// pause waits for d unless the sequence is stopped first.
+func (q *Queue) pause(ctx context.Context, d time.Duration) {
+	q.mu.Lock()
+	q.gapStart = time.Now()
+	q.gap = d
+	q.mu.Unlock()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	q.gap = 0
+	q.mu.Unlock()
+}
+
+// Stop implements Player. It stops the current sequence, fading out its
+// sound if the player allows, and drops everything queued after it.
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	q.pending = nil
+	q.stopping = true
+	cancel, done := q.cancel, q.done
+	running := q.running
+	q.mu.Unlock()
+	if !running {
+		return
+	}
+	q.player.Stop()
+	if cancel != nil {
+		cancel()
+	}
+	<-done
+}
+
+// State implements Player. The queue is Playing for the whole of a
+// sequence, pauses included.
+func (q *Queue) State() State {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running {
+		return Playing
+	}
+	return Idle
+}
+
+// SetVolume implements Player.
+func (q *Queue) SetVolume(volume int) { q.player.SetVolume(volume) }
+
+// Volume implements Player.
+func (q *Queue) Volume() int { return q.player.Volume() }
+
+// Progress implements Player. During a pause it reports the pause, with an
+// empty Sound.
+func (q *Queue) Progress() Progress {
+	q.mu.Lock()
+	gap, start := q.gap, q.gapStart
+	q.mu.Unlock()
+	if gap > 0 {
+		return progressAt("", start, time.Now(), gap)
+	}
+	return q.player.Progress()
+}
+
+// Events implements Player.
+func (q *Queue) Events() <-chan Event {
+	return q.events
+}
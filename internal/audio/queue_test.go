@@ -0,0 +1,144 @@
+package audio
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// expectStarted waits for the queue to report that sound has started.
+func expectStarted(t *testing.T, q *Queue, sound string) {
+	t.Helper()
+	if e := nextEvent(t, q.Events()); e.Kind != EventStarted || e.Sound != sound {
+		t.Fatalf("expected %s to start, got %v", sound, e)
+	}
+}
+
+func TestQueue_SequenceWithGap(t *testing.T) {
+	fake := NewFakePlayer()
+	q := NewQueue(fake)
+	seq := Sequence{{Sound: "azan.mp3"}, {Gap: 50 * time.Millisecond}, {Sound: "dua.mp3"}}
+
+	if err := q.PlaySequence(context.Background(), testFS, seq); err != nil {
+		t.Fatalf("PlaySequence failed: %v", err)
+	}
+	expectStarted(t, q, "azan.mp3")
+	fake.Finish()
+	nextEvent(t, q.Events())
+	finished := time.Now()
+
+	if q.State() != Playing {
+		t.Error("expected the queue to stay Playing during the gap")
+	}
+	expectStarted(t, q, "dua.mp3")
+	if gap := time.Since(finished); gap < 50*time.Millisecond {
+		t.Errorf("dua started %v after the azan, before the gap ended", gap)
+	}
+	fake.Finish()
+	nextEvent(t, q.Events())
+
+	waitIdle(t, q)
+	if got := fake.Played(); !slices.Equal(got, []string{"azan.mp3", "dua.mp3"}) {
+		t.Errorf("unexpected sounds played: %v", got)
+	}
+}
+
+func TestQueue_QueuesInsteadOfBusy(t *testing.T) {
+	fake := NewFakePlayer()
+	q := NewQueue(fake)
+
+	q.Play(context.Background(), testFS, "azan.mp3")
+	if err := q.Play(context.Background(), testFS, "dua.mp3"); err != nil {
+		t.Fatalf("expected second Play to be queued, got %v", err)
+	}
+	expectStarted(t, q, "azan.mp3")
+	fake.Finish()
+	nextEvent(t, q.Events())
+	expectStarted(t, q, "dua.mp3")
+	fake.Finish()
+	waitIdle(t, q)
+}
+
+func TestQueue_StopEndsSequenceAndQueue(t *testing.T) {
+	fake := NewFakePlayer()
+	q := NewQueue(fake)
+	q.PlaySequence(context.Background(), testFS, Sequence{{Sound: "azan.mp3"}, {Gap: time.Hour}, {Sound: "dua.mp3"}})
+	q.Play(context.Background(), testFS, "later.mp3")
+	expectStarted(t, q, "azan.mp3")
+
+	q.Stop()
+	if q.State() != Idle {
+		t.Errorf("expected Idle after Stop, got %v", q.State())
+	}
+	if e := nextEvent(t, q.Events()); e.Kind != EventStopped {
+		t.Errorf("expected stopped event, got %v", e)
+	}
+	if got := fake.Played(); !slices.Equal(got, []string{"azan.mp3"}) {
+		t.Errorf("expected nothing after Stop, played %v", got)
+	}
+}
+
+func TestQueue_StopDuringGap(t *testing.T) {
+	fake := NewFakePlayer()
+	q := NewQueue(fake)
+	q.PlaySequence(context.Background(), testFS, Sequence{{Sound: "azan.mp3"}, {Gap: time.Hour}, {Sound: "dua.mp3"}})
+	expectStarted(t, q, "azan.mp3")
+	fake.Finish()
+	nextEvent(t, q.Events())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Progress().Duration != time.Hour {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the gap")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() { q.Stop(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not end the gap")
+	}
+	if got := fake.Played(); len(got) != 1 {
+		t.Errorf("expected dua not to play after Stop, played %v", got)
+	}
+}
+
+func TestQueue_VolumeFor(t *testing.T) {
+	fake := NewFakePlayer()
+	q := NewQueue(fake)
+	q.SetVolumeFor(func(sound string) int {
+		if sound == "dua.mp3" {
+			return 40
+		}
+		return 90
+	})
+
+	q.PlaySequence(context.Background(), testFS, Sequence{{Sound: "azan.mp3"}, {Sound: "dua.mp3"}})
+	expectStarted(t, q, "azan.mp3")
+	if v := q.Volume(); v != 90 {
+		t.Errorf("expected azan at volume 90, got %d", v)
+	}
+	fake.Finish()
+	nextEvent(t, q.Events())
+	expectStarted(t, q, "dua.mp3")
+	if v := q.Volume(); v != 40 {
+		t.Errorf("expected dua at volume 40, got %d", v)
+	}
+	q.Stop()
+}
+
+// waitIdle waits for q to finish everything queued.
+func waitIdle(t *testing.T, q *Queue) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for q.State() != Idle {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the queue to go idle")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
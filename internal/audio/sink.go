@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"io"
+	"time"
+)
+
+// PipeSink writes raw PCM to w, e.g. the standard input of
+// "aplay -t raw -f S16_LE". w is closed with the sink if it is an io.Closer.
+type PipeSink struct {
+	w io.Writer
+}
+
+// NewPipeSink creates a PipeSink writing to w.
+func NewPipeSink(w io.Writer) *PipeSink {
+	return &PipeSink{w: w}
+}
+
+// Open implements Sink.
+func (p *PipeSink) Open(s Stream) error { return nil }
+
+// Write implements Sink.
+func (p *PipeSink) Write(pcm []byte) (int, error) { return p.w.Write(pcm) }
+
+// Close implements Sink.
+func (p *PipeSink) Close() error {
+	if c, ok := p.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// NullSink discards PCM and measures how long it would have played.
+type NullSink struct {
+	stream Stream
+	bytes  int64
+}
+
+// Open implements Sink.
+func (n *NullSink) Open(s Stream) error {
+	n.stream = s
+	return nil
+}
+
+// Write implements Sink.
+func (n *NullSink) Write(pcm []byte) (int, error) {
+	n.bytes += int64(len(pcm))
+	return len(pcm), nil
+}
+
+// Close implements Sink.
+func (n *NullSink) Close() error { return nil }
+
+// Stream returns the format of the decoded audio.
+func (n *NullSink) Stream() Stream { return n.stream }
+
+// Bytes returns how many bytes of PCM were written.
+func (n *NullSink) Bytes() int64 { return n.bytes }
+
+// Duration returns the playing time of everything written so far.
+func (n *NullSink) Duration() time.Duration {
+	return n.stream.Duration(n.bytes)
+}
@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// SynthRate is the sample rate of synthesized sounds, which are mono.
+const SynthRate = 22050
+
+// synthPeak is the loudest sample a synthesized sound reaches, as a
+// fraction of full scale, leaving headroom for players that resample.
+const synthPeak = 0.8
+
+// Partial is one component of a Timbre.
+type Partial struct {
+	Ratio float64 // multiple of the tone's frequency
+	Gain  float64 // level relative to the other partials
+	Decay float64 // scales the envelope's decay; 0 means 1
+}
+
+// Timbre is the mix of partials that gives a tone its character.
+type Timbre []Partial
+
+var (
+	// Sine is a pure tone.
+	Sine = Timbre{{Ratio: 1, Gain: 1}}
+	// Bell approximates a tuned bell: a hum an octave below, the
+	// fundamental, a minor third, a fifth and higher partials that die away
+	// faster.
+	Bell = Timbre{
+		{Ratio: 0.5, Gain: 0.35, Decay: 1.6},
+		{Ratio: 1, Gain: 1, Decay: 1},
+		{Ratio: 1.2, Gain: 0.4, Decay: 0.6},
+		{Ratio: 1.5, Gain: 0.25, Decay: 0.5},
+		{Ratio: 2, Gain: 0.3, Decay: 0.4},
+		{Ratio: 2.76, Gain: 0.15, Decay: 0.25},
+	}
+)
+
+// Envelope shapes a tone's loudness over time.
+type Envelope struct {
+	Attack  time.Duration // rise from silence to full level
+	Decay   time.Duration // time to fall by half after the attack; 0 holds the level
+	Release time.Duration // fade to silence at the end of the tone
+}
+
+// level returns the envelope at t into a tone of the given length, with the
+// decay scaled by decayScale.
+func (e Envelope) level(t, length time.Duration, decayScale float64) float64 {
+	l := 1.0
+	if e.Attack > 0 && t < e.Attack {
+		l = t.Seconds() / e.Attack.Seconds()
+	}
+	if e.Decay > 0 && t > e.Attack {
+		l *= math.Exp2(-(t - e.Attack).Seconds() / (e.Decay.Seconds() * decayScale))
+	}
+	if e.Release > 0 && t > length-e.Release {
+		l *= max(0, (length-t).Seconds()/e.Release.Seconds())
+	}
+	return l
+}
+
+// Tone is a note placed in a synthesized sound.
+type Tone struct {
+	Freq     float64       // Hz
+	At       time.Duration // start, from the beginning of the sound
+	Length   time.Duration
+	Gain     float64 // relative to the other tones
+	Timbre   Timbre
+	Envelope Envelope
+}
+
+// Synthesize mixes tones into mono PCM at rate. The mix is scaled so that
+// its loudest sample is just below full scale.
+func Synthesize(rate int, tones []Tone) (Stream, []byte) {
+	var end time.Duration
+	for _, t := range tones {
+		end = max(end, t.At+t.Length)
+	}
+	mix := make([]float64, int(end.Seconds()*float64(rate)))
+
+	for _, t := range tones {
+		first := int(t.At.Seconds() * float64(rate))
+		n := int(t.Length.Seconds() * float64(rate))
+		for _, p := range t.Timbre {
+			decay := p.Decay
+			if decay == 0 {
+				decay = 1
+			}
+			w := 2 * math.Pi * t.Freq * p.Ratio / float64(rate)
+			for i := 0; i < n && first+i < len(mix); i++ {
+				at := time.Duration(i) * time.Second / time.Duration(rate)
+				mix[first+i] += t.Gain * p.Gain * t.Envelope.level(at, t.Length, decay) * math.Sin(w*float64(i))
+			}
+		}
+	}
+
+	peak := 0.0
+	for _, v := range mix {
+		peak = max(peak, math.Abs(v))
+	}
+	scale := 0.0
+	if peak > 0 {
+		scale = synthPeak * math.MaxInt16 / peak
+	}
+	pcm := make([]byte, 2*len(mix))
+	for i, v := range mix {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(int16(math.Round(v*scale))))
+	}
+	return Stream{SampleRate: rate, Channels: 1, Size: int64(len(pcm))}, pcm
+}
+
+// SynthWAV returns tones mixed at rate as a WAV file.
+func SynthWAV(rate int, tones []Tone) []byte {
+	s, pcm := Synthesize(rate, tones)
+	var buf bytes.Buffer
+	writeWAVHeader(&buf, s, uint32(len(pcm)))
+	buf.Write(pcm)
+	return buf.Bytes()
+}
@@ -0,0 +1,74 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSynthesize_Sine(t *testing.T) {
+	s, pcm := Synthesize(8000, []Tone{{Freq: 440, Length: time.Second, Gain: 1, Timbre: Sine}})
+	if s.SampleRate != 8000 || s.Channels != 1 || s.Size != int64(len(pcm)) {
+		t.Fatalf("unexpected stream %+v for %d bytes", s, len(pcm))
+	}
+	samples := samples(pcm)
+	if len(samples) != 8000 {
+		t.Fatalf("expected one second of samples, got %d", len(samples))
+	}
+
+	crossings, peak := 0, 0
+	for i, v := range samples {
+		peak = max(peak, int(math.Abs(float64(v))))
+		if i > 0 && (samples[i-1] < 0) != (v < 0) {
+			crossings++
+		}
+	}
+	// A 440 Hz sine crosses zero 880 times a second.
+	if crossings < 875 || crossings > 885 {
+		t.Errorf("expected about 880 zero crossings, got %d", crossings)
+	}
+	if want := int(math.Round(synthPeak * math.MaxInt16)); peak < want-1 || peak > want+1 {
+		t.Errorf("expected the mix to peak at %d, got %d", want, peak)
+	}
+}
+
+func TestSynthesize_Envelope(t *testing.T) {
+	env := Envelope{Attack: 100 * time.Millisecond, Decay: 100 * time.Millisecond, Release: 100 * time.Millisecond}
+	_, pcm := Synthesize(1000, []Tone{{Freq: 250, Length: time.Second, Gain: 1, Timbre: Sine, Envelope: env}})
+	s := samples(pcm)
+
+	// The 250 Hz sine peaks at the 1st sample of every 4.
+	if s[1] > s[101]/10 {
+		t.Errorf("expected the attack to start near silence, got %d against %d", s[1], s[101])
+	}
+	if half := float64(s[201]) / float64(s[101]); half < 0.45 || half > 0.55 {
+		t.Errorf("expected the level to halve after one decay time, got ratio %.2f", half)
+	}
+	if s[len(s)-3] != 0 && math.Abs(float64(s[len(s)-3])) > math.Abs(float64(s[801]))/10 {
+		t.Errorf("expected the release to end near silence, got %d", s[len(s)-3])
+	}
+}
+
+func TestSynthWAV_Decodes(t *testing.T) {
+	wav := SynthWAV(SynthRate, []Tone{{Freq: 880, Length: 150 * time.Millisecond, Gain: 1, Timbre: Sine}})
+	if DetectFormat(wav, "") != FormatWAV {
+		t.Fatalf("expected a WAV header, got %q", wav[:12])
+	}
+
+	var sink NullSink
+	if err := DecodeWAV(context.Background(), bytes.NewReader(wav), &sink); err != nil {
+		t.Fatalf("DecodeWAV failed: %v", err)
+	}
+	if got := sink.Duration(); (got - 150*time.Millisecond).Abs() > time.Second/SynthRate {
+		t.Errorf("expected 150ms of audio, got %v", got)
+	}
+	if sink.Stream().Channels != 1 || sink.Stream().SampleRate != SynthRate {
+		t.Errorf("unexpected stream %+v", sink.Stream())
+	}
+
+	if err := DecodeWAV(context.Background(), bytes.NewReader([]byte("RIFF")), &NullSink{}); err == nil {
+		t.Error("expected error for a truncated WAV, got nil")
+	}
+}
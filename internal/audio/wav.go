@@ -0,0 +1,215 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// wavHeaderSize is the length of the canonical PCM WAV header.
+const wavHeaderSize = 44
+
+// wavUnknownSize fills the size fields of a WAV streamed to a writer that
+// cannot seek back to fix them; players treat it as "until end of file".
+const wavUnknownSize = 0xFFFFFFFF
+
+// writeWAVHeader writes a canonical 44-byte PCM WAV header for dataSize
+// bytes of 16-bit samples.
+func writeWAVHeader(w io.Writer, s Stream, dataSize uint32) error {
+	blockAlign := s.Channels * pcmBitsPerSample / 8
+	chunkSize := dataSize
+	if dataSize != wavUnknownSize {
+		chunkSize = 36 + dataSize
+	}
+	header := struct {
+		Riff          [4]byte
+		ChunkSize     uint32
+		Wave          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		Riff:          [4]byte{'R', 'I', 'F', 'F'},
+		ChunkSize:     chunkSize,
+		Wave:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		AudioFormat:   1, // PCM
+		Channels:      uint16(s.Channels),
+		SampleRate:    uint32(s.SampleRate),
+		ByteRate:      uint32(s.BytesPerSecond()),
+		BlockAlign:    uint16(blockAlign),
+		BitsPerSample: pcmBitsPerSample,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      dataSize,
+	}
+	return binary.Write(w, binary.LittleEndian, header)
+}
+
+// WAVSink writes PCM as a WAV file. If the stream size is unknown the header
+// is patched on Close when w is an io.WriteSeeker. w is closed with the sink
+// if it is an io.Closer.
+type WAVSink struct {
+	w       io.Writer
+	written int64
+	sized   bool // header already carries the real size
+}
+
+// NewWAVSink creates a WAVSink writing to w.
+func NewWAVSink(w io.Writer) *WAVSink {
+	return &WAVSink{w: w}
+}
+
+// Open implements Sink.
+func (s *WAVSink) Open(st Stream) error {
+	size := uint32(wavUnknownSize)
+	if st.Size >= 0 {
+		size = uint32(st.Size)
+		s.sized = true
+	}
+	return writeWAVHeader(s.w, st, size)
+}
+
+// Write implements Sink.
+func (s *WAVSink) Write(pcm []byte) (int, error) {
+	n, err := s.w.Write(pcm)
+	s.written += int64(n)
+	return n, err
+}
+
+// Close implements Sink.
+func (s *WAVSink) Close() error {
+	var err error
+	if ws, ok := s.w.(io.WriteSeeker); ok && !s.sized {
+		err = patchWAVSizes(ws, uint32(s.written))
+	}
+	if c, ok := s.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// patchWAVSizes rewrites the RIFF and data chunk sizes of a WAV header.
+func patchWAVSizes(ws io.WriteSeeker, dataSize uint32) error {
+	var b [4]byte
+	for _, f := range []struct {
+		offset int64
+		value  uint32
+	}{{4, 36 + dataSize}, {40, dataSize}} {
+		if _, err := ws.Seek(f.offset, io.SeekStart); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(b[:], f.value)
+		if _, err := ws.Write(b[:]); err != nil {
+			return err
+		}
+	}
+	_, err := ws.Seek(0, io.SeekEnd)
+	return err
+}
+
+// parseWAV returns the format and samples of a 16-bit PCM WAV file. A data
+// chunk whose size is unknown or too large runs to the end of the file.
+func parseWAV(data []byte) (Stream, []byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return Stream{}, nil, errors.New("not a WAV file")
+	}
+	var s Stream
+	for off := 12; off+8 <= len(data); {
+		id := string(data[off : off+4])
+		size := binary.LittleEndian.Uint32(data[off+4:])
+		body := data[off+8:]
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return Stream{}, nil, errors.New("short WAV format chunk")
+			}
+			format := binary.LittleEndian.Uint16(body)
+			bits := binary.LittleEndian.Uint16(body[14:])
+			if format != 1 || bits != pcmBitsPerSample {
+				return Stream{}, nil, fmt.Errorf("unsupported WAV encoding %d with %d-bit samples", format, bits)
+			}
+			s.Channels = int(binary.LittleEndian.Uint16(body[2:]))
+			s.SampleRate = int(binary.LittleEndian.Uint32(body[4:]))
+		case "data":
+			if s.SampleRate == 0 || s.Channels == 0 {
+				return Stream{}, nil, errors.New("WAV data before format")
+			}
+			if size == wavUnknownSize || int64(size) > int64(len(body)) {
+				size = uint32(len(body))
+			}
+			pcm := body[:size]
+			pcm = pcm[:len(pcm)-len(pcm)%(s.Channels*pcmBitsPerSample/8)]
+			s.Size = int64(len(pcm))
+			return s, pcm, nil
+		}
+		// Chunks are padded to an even length.
+		off += 8 + int(size) + int(size&1)
+	}
+	return Stream{}, nil, errors.New("WAV file has no data")
+}
+
+// wavDuration returns how long a PCM WAV file plays, or 0 if it cannot be
+// parsed.
+func wavDuration(data []byte) time.Duration {
+	s, _, err := parseWAV(data)
+	if err != nil {
+		return 0
+	}
+	return s.Duration(s.Size)
+}
+
+// DecodeWAV feeds the samples of a 16-bit PCM WAV file into sink and closes
+// the sink. It stops early if ctx is cancelled.
+func DecodeWAV(ctx context.Context, r io.Reader, sink Sink) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		sink.Close()
+		return fmt.Errorf("read wav: %w", err)
+	}
+	s, pcm, err := parseWAV(data)
+	if err != nil {
+		sink.Close()
+		return fmt.Errorf("decode wav: %w", err)
+	}
+	if err := sink.Open(s); err != nil {
+		sink.Close()
+		return err
+	}
+	chunk := decodeChunk / pcmFrameSize * s.Channels * pcmBitsPerSample / 8
+	for len(pcm) > 0 {
+		if err := ctx.Err(); err != nil {
+			sink.Close()
+			return err
+		}
+		n := min(chunk, len(pcm))
+		if _, err := sink.Write(pcm[:n]); err != nil {
+			sink.Close()
+			return err
+		}
+		pcm = pcm[n:]
+	}
+	return sink.Close()
+}
+
+// mp3ToWAV decodes an MP3 into a 16-bit stereo WAV file.
+func mp3ToWAV(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := DecodeMP3(context.Background(), bytes.NewReader(data), NewWAVSink(&buf)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
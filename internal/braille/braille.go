@@ -0,0 +1,135 @@
+// Package braille draws on a canvas of Braille dots, two across and four
+// down in each character cell, for graphics finer than the characters of a
+// terminal.
+package braille
+
+import "strings"
+
+// dots maps a dot's column and row within a cell to its bit in the
+// character.
+var dots = [2][4]rune{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+
+// blank is the Braille character without dots.
+const blank = 0x2800
+
+// Canvas is a grid of dots. The origin is the top left, with y going down.
+type Canvas struct {
+	width, height int
+	cells         [][]rune
+}
+
+// New returns a blank canvas width dots across and height dots down.
+func New(width, height int) *Canvas {
+	c := &Canvas{width: max(0, width), height: max(0, height)}
+	c.cells = make([][]rune, (c.height+3)/4)
+	for i := range c.cells {
+		c.cells[i] = make([]rune, (c.width+1)/2)
+		for j := range c.cells[i] {
+			c.cells[i][j] = blank
+		}
+	}
+	return c
+}
+
+// Width returns the canvas's width in dots.
+func (c *Canvas) Width() int { return c.width }
+
+// Height returns the canvas's height in dots.
+func (c *Canvas) Height() int { return c.height }
+
+// in reports whether the dot at x, y is on the canvas.
+func (c *Canvas) in(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.width && y < c.height
+}
+
+// Set draws the dot at x, y. Dots off the canvas are ignored, so that
+// shapes may run over its edges.
+func (c *Canvas) Set(x, y int) {
+	if c.in(x, y) {
+		c.cells[y/4][x/2] |= dots[x%2][y%4]
+	}
+}
+
+// Unset clears the dot at x, y.
+func (c *Canvas) Unset(x, y int) {
+	if c.in(x, y) {
+		c.cells[y/4][x/2] &^= dots[x%2][y%4]
+	}
+}
+
+// Get reports whether the dot at x, y is drawn.
+func (c *Canvas) Get(x, y int) bool {
+	return c.in(x, y) && c.cells[y/4][x/2]&dots[x%2][y%4] != 0
+}
+
+// Line draws a straight line from x0, y0 to x1, y1, both ends included.
+func (c *Canvas) Line(x0, y0, x1, y1 int) {
+	// Bresenham's algorithm, for lines in any direction.
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	e := dx + dy
+	for {
+		c.Set(x0, y0)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// Circle draws a circle of radius r around cx, cy.
+func (c *Canvas) Circle(cx, cy, r int) {
+	// The midpoint algorithm: walk one eighth of the circle and mirror it.
+	x, y, e := r, 0, 1-r
+	for x >= y {
+		for _, p := range [][2]int{{x, y}, {y, x}, {-y, x}, {-x, y}, {-x, -y}, {-y, -x}, {y, -x}, {x, -y}} {
+			c.Set(cx+p[0], cy+p[1])
+		}
+		y++
+		if e < 0 {
+			e += 2*y + 1
+		} else {
+			x--
+			e += 2*(y-x) + 1
+		}
+	}
+}
+
+// Rows returns the canvas as lines of Braille characters.
+func (c *Canvas) Rows() []string {
+	rows := make([]string, len(c.cells))
+	for i, cells := range c.cells {
+		rows[i] = string(cells)
+	}
+	return rows
+}
+
+// String returns the canvas as lines of Braille characters.
+func (c *Canvas) String() string {
+	return strings.Join(c.Rows(), "\n")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package braille
+
+import (
+	"testing"
+)
+
+func TestCanvas_Set(t *testing.T) {
+	c := New(3, 5)
+	if c.String() != "⠀⠀\n⠀⠀" {
+		t.Fatalf("expected two blank rows of two cells, got %q", c.String())
+	}
+	c.Set(0, 0)
+	c.Set(1, 3)
+	c.Set(2, 4)
+	c.Set(-1, 0) // off the canvas
+	c.Set(3, 0)
+	if got := c.String(); got != "⢁⠀\n⠀⠁" {
+		t.Errorf("got %q", got)
+	}
+	if !c.Get(1, 3) || c.Get(1, 2) || c.Get(9, 9) {
+		t.Error("Get does not match what was set")
+	}
+	c.Unset(1, 3)
+	if c.Get(1, 3) || !c.Get(0, 0) {
+		t.Error("expected Unset to clear only its own dot")
+	}
+}
+
+func TestCanvas_Line(t *testing.T) {
+	c := New(8, 8)
+	c.Line(0, 0, 7, 7)
+	c.Line(7, 0, 0, 7)
+	for i := 0; i < 8; i++ {
+		if !c.Get(i, i) || !c.Get(7-i, i) {
+			t.Errorf("expected both diagonals through row %d", i)
+		}
+	}
+	c = New(8, 8)
+	c.Line(6, 2, 1, 2)
+	for x := 0; x < 8; x++ {
+		if c.Get(x, 2) != (x >= 1 && x <= 6) {
+			t.Errorf("horizontal line at x=%d: %v", x, c.Get(x, 2))
+		}
+	}
+	c = New(2, 2)
+	c.Line(1, 1, 1, 1)
+	if !c.Get(1, 1) {
+		t.Error("expected a line of one point to draw it")
+	}
+}
+
+func TestCanvas_Circle(t *testing.T) {
+	c := New(21, 21)
+	c.Circle(10, 10, 10)
+	for _, p := range [][2]int{{0, 10}, {20, 10}, {10, 0}, {10, 20}} {
+		if !c.Get(p[0], p[1]) {
+			t.Errorf("expected the circle through %v", p)
+		}
+	}
+	if c.Get(10, 10) || c.Get(5, 5) {
+		t.Error("expected the circle to be hollow")
+	}
+	// It is symmetric about both axes.
+	for y := 0; y < 21; y++ {
+		for x := 0; x < 21; x++ {
+			if c.Get(x, y) != c.Get(20-x, y) || c.Get(x, y) != c.Get(x, 20-y) {
+				t.Fatalf("not symmetric at %d,%d", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Package broadcast shares the azan with other machines on the local
+// network. A Server publishes what is playing over HTTP, and optionally UDP
+// multicast; Clients fetch the sounds and play them in step with it.
+package broadcast
+
+import (
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/audio"
+)
+
+// Lead is how far ahead plays are announced, giving clients time to fetch
+// the sounds so that every room starts together.
+const Lead = 2 * time.Second
+
+// DefaultGroup is the multicast group announcements are sent to unless
+// another is given.
+const DefaultGroup = "239.255.77.77:7777"
+
+// Announcement tells clients what the server is playing.
+type Announcement struct {
+	// Server identifies a run of the server; Seq numbers its announcements.
+	Server string `json:"server"`
+	Seq    uint64 `json:"seq"`
+	// Playing is false once playback has been stopped.
+	Playing bool   `json:"playing"`
+	Title   string `json:"title,omitempty"`
+	Steps   []Step `json:"steps,omitempty"`
+	// StartAt is when the first step starts, by the server's clock.
+	StartAt time.Time `json:"start_at"`
+	// Now is the server's clock when the announcement was sent.
+	Now time.Time `json:"now"`
+}
+
+// Step is a sound, or a pause of Gap when Sound is empty.
+type Step struct {
+	Sound string        `json:"sound,omitempty"`
+	Gap   time.Duration `json:"gap,omitempty"`
+}
+
+// stepsOf converts a sequence for an announcement.
+func stepsOf(seq audio.Sequence) []Step {
+	steps := make([]Step, len(seq))
+	for i, st := range seq {
+		steps[i] = Step{Sound: st.Sound, Gap: st.Gap}
+	}
+	return steps
+}
+
+// sequence converts the announcement's steps back into a sequence.
+func (a Announcement) sequence() audio.Sequence {
+	seq := make(audio.Sequence, len(a.Steps))
+	for i, st := range a.Steps {
+		seq[i] = audio.Step{Sound: st.Sound, Gap: st.Gap}
+	}
+	return seq
+}
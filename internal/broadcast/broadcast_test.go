@@ -0,0 +1,326 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/audio"
+)
+
+var testSounds = fstest.MapFS{
+	"azan1.mp3": {Data: []byte("mp3 data")},
+	"dua.wav":   {Data: []byte("wav data")},
+}
+
+// fakePlayer records what it is asked to play, reading every sound.
+type fakePlayer struct {
+	mu     sync.Mutex
+	played []audio.Sequence
+	data   map[string]string
+	stops  int
+	ch     chan audio.Sequence
+}
+
+func newFakePlayer() *fakePlayer {
+	return &fakePlayer{data: make(map[string]string), ch: make(chan audio.Sequence, 10)}
+}
+
+func (p *fakePlayer) PlaySequence(ctx context.Context, fsys fs.FS, seq audio.Sequence) error {
+	p.mu.Lock()
+	for _, st := range seq {
+		if st.Sound != "" {
+			data, err := fs.ReadFile(fsys, st.Sound)
+			if err != nil {
+				p.mu.Unlock()
+				return err
+			}
+			p.data[st.Sound] = string(data)
+		}
+	}
+	p.played = append(p.played, seq)
+	p.mu.Unlock()
+	p.ch <- seq
+	return nil
+}
+
+func (p *fakePlayer) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stops++
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer(testSounds)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	if code, _ := get(t, ts.URL+"/stream"); code != http.StatusNotFound {
+		t.Errorf("expected no stream while idle, got %d", code)
+	}
+	if code, body := get(t, ts.URL+"/sounds/dua.wav"); code != http.StatusOK || body != "wav data" {
+		t.Errorf("GET /sounds/dua.wav = %d %q", code, body)
+	}
+	if code, _ := get(t, ts.URL+"/sounds/../secret"); code != http.StatusNotFound {
+		t.Errorf("expected paths outside the sounds to be refused, got %d", code)
+	}
+
+	seq := s.Play("Maghrib", audio.Sequence{{Sound: "azan1.mp3"}, {Gap: 3 * time.Second}, {Sound: "dua.wav"}})
+	if seq[0] != (audio.Step{Gap: Lead}) || len(seq) != 4 {
+		t.Errorf("expected the sequence to be delayed by Lead, got %v", seq)
+	}
+
+	code, body := get(t, ts.URL+"/status")
+	var a Announcement
+	if err := json.Unmarshal([]byte(body), &a); err != nil || code != http.StatusOK {
+		t.Fatalf("GET /status = %d %q", code, body)
+	}
+	if !a.Playing || a.Title != "Maghrib" || a.Seq != 1 || len(a.Steps) != 3 || a.Server == "" {
+		t.Errorf("unexpected announcement %+v", a)
+	}
+	if d := a.StartAt.Sub(a.Now); d <= 0 || d > Lead {
+		t.Errorf("expected the start within Lead of now, got %v", d)
+	}
+
+	resp, err := http.Get(ts.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != "mp3 data" || resp.Header.Get("Content-Type") != "audio/mpeg" {
+		t.Errorf("GET /stream = %q (%s)", data, resp.Header.Get("Content-Type"))
+	}
+
+	s.Stop()
+	_, body = get(t, ts.URL+"/status")
+	json.Unmarshal([]byte(body), &a)
+	if a.Playing || a.Seq != 2 {
+		t.Errorf("expected a stop announcement, got %+v", a)
+	}
+
+	s.Play("Isha", audio.Sequence{{Sound: "azan1.mp3"}})
+	s.Finish()
+	_, body = get(t, ts.URL+"/status")
+	json.Unmarshal([]byte(body), &a)
+	if a.Playing || a.Seq != 3 {
+		t.Errorf("expected a finished play without a new announcement, got %+v", a)
+	}
+	if code, _ := get(t, ts.URL+"/stream"); code != http.StatusNotFound {
+		t.Errorf("expected no stream once finished, got %d", code)
+	}
+}
+
+func TestClient_Follows(t *testing.T) {
+	s := NewServer(testSounds)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	player := newFakePlayer()
+	c, err := NewClient(ts.URL, player)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.poll(ctx); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if st := c.Status(); !st.Connected || st.Err != nil {
+		t.Errorf("expected to be connected, got %+v", st)
+	}
+
+	s.Play("Asr", audio.Sequence{{Sound: "azan1.mp3"}, {Gap: time.Second}, {Sound: "dua.wav"}})
+	start := time.Now()
+	if err := c.poll(ctx); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	select {
+	case seq := <-player.ch:
+		want := audio.Sequence{{Sound: "azan1.mp3"}, {Gap: time.Second}, {Sound: "dua.wav"}}
+		if !reflect.DeepEqual(seq, want) {
+			t.Errorf("played %v, want %v", seq, want)
+		}
+		if waited := time.Since(start); waited < Lead-200*time.Millisecond {
+			t.Errorf("expected playback to wait for the announced start, waited %v", waited)
+		}
+	case <-time.After(2 * Lead):
+		t.Fatal("announcement not played")
+	}
+	player.mu.Lock()
+	if player.data["dua.wav"] != "wav data" {
+		t.Errorf("expected sounds to come from the server, got %v", player.data)
+	}
+	player.mu.Unlock()
+
+	// Seeing the same announcement again does nothing.
+	c.poll(ctx)
+	select {
+	case <-player.ch:
+		t.Error("announcement played twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestClient_StopBeforeStart(t *testing.T) {
+	s := NewServer(testSounds)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	player := newFakePlayer()
+	c, _ := NewClient(ts.URL, player)
+
+	s.Play("Isha", audio.Sequence{{Sound: "azan1.mp3"}})
+	c.poll(context.Background())
+	s.Stop()
+	c.poll(context.Background())
+	select {
+	case <-player.ch:
+		t.Error("expected a stopped announcement not to play")
+	case <-time.After(Lead + 500*time.Millisecond):
+	}
+	player.mu.Lock()
+	defer player.mu.Unlock()
+	if player.stops == 0 {
+		t.Error("expected the player to be stopped")
+	}
+}
+
+func TestClient_Skew(t *testing.T) {
+	const skew = time.Hour
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().Add(skew)
+		// The server's clock is an hour fast; its azan started a second
+		// ago by that clock.
+		json.NewEncoder(w).Encode(Announcement{
+			Server: "a", Seq: 1, Playing: true,
+			Steps:   []Step{{Sound: "azan1.mp3"}},
+			StartAt: now.Add(-time.Second), Now: now,
+		})
+	}))
+	defer ts.Close()
+	player := newFakePlayer()
+	c, _ := NewClient(ts.URL, player)
+	c.sounds.files["azan1.mp3"] = []byte("cached")
+
+	if err := c.poll(context.Background()); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if off := c.Status().Offset; off < skew-time.Second || off > skew+time.Second {
+		t.Errorf("expected an offset of about %v, got %v", skew, off)
+	}
+	select {
+	case <-player.ch:
+	case <-time.After(time.Second):
+		t.Error("expected a sequence that started a second ago to be joined")
+	}
+}
+
+func TestClient_TooLate(t *testing.T) {
+	player := newFakePlayer()
+	c, _ := NewClient("http://127.0.0.1:1", player)
+	c.handle(context.Background(), Announcement{
+		Server: "a", Seq: 1, Playing: true,
+		Steps:   []Step{{Sound: "azan1.mp3"}},
+		StartAt: time.Now().Add(-time.Minute),
+	})
+	select {
+	case <-player.ch:
+		t.Error("expected a sequence that started a minute ago to be skipped")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestClient_Reconnects(t *testing.T) {
+	var up atomic.Bool
+	s := NewServer(testSounds)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			http.Error(w, "restarting", http.StatusServiceUnavailable)
+			return
+		}
+		s.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+	c, _ := NewClient(ts.URL, newFakePlayer())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Run(ctx, "") }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run failed: %v", err)
+		}
+	}()
+
+	waitFor := func(what string, cond func(Status) bool) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for !cond(c.Status()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s: %+v", what, c.Status())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitFor("an error", func(st Status) bool { return st.Err != nil && !st.Connected })
+	up.Store(true)
+	waitFor("the connection", func(st Status) bool { return st.Connected && st.Last.Server != "" })
+}
+
+func TestClient_Multicast(t *testing.T) {
+	const group = "239.255.77.78:7778"
+	conn, err := listenMulticast(group)
+	if err != nil {
+		t.Skipf("multicast not available: %v", err)
+	}
+	conn.Close()
+
+	s := NewServer(testSounds)
+	if err := s.Multicast(group); err != nil {
+		t.Skipf("multicast not available: %v", err)
+	}
+	defer s.Close()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	player := newFakePlayer()
+	c, _ := NewClient(ts.URL, player)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx, group)
+	for c.Status().Last.Server == "" {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Announced over multicast, the play is picked up before the next poll
+	// could have seen it.
+	s.Play("Fajr", audio.Sequence{{Sound: "azan1.mp3"}})
+	deadline := time.Now().Add(pollInterval / 2)
+	for c.Status().Last.Seq != 1 {
+		if time.Now().After(deadline) {
+			t.Skip("multicast datagrams are not looped back here")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
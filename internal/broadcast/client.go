@@ -0,0 +1,336 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/audio"
+)
+
+// Client timing.
+const (
+	// pollInterval is how often the server's status is fetched.
+	pollInterval = time.Second
+	// maxBackoff caps the wait between attempts while the server is
+	// unreachable.
+	maxBackoff = 30 * time.Second
+	// joinWindow is how late a client may start a sequence, such as after
+	// reconnecting. Later than this it would be audibly out of step.
+	joinWindow = 5 * time.Second
+	// skewSamples is how many clock measurements are kept. The one with
+	// the quickest round trip is the most accurate.
+	skewSamples = 8
+)
+
+// Player plays announced sequences; audio.Queue is one.
+type Player interface {
+	PlaySequence(ctx context.Context, fsys fs.FS, seq audio.Sequence) error
+	Stop()
+}
+
+// Status describes a Client's connection to its server.
+type Status struct {
+	Connected bool
+	// Offset is how far the server's clock is ahead of ours.
+	Offset time.Duration
+	// Err is why the server cannot be reached, if it cannot.
+	Err error
+	// Last is the last announcement acted on.
+	Last Announcement
+}
+
+// skewSample is one measurement of the server's clock.
+type skewSample struct {
+	offset, rtt time.Duration
+}
+
+// Client follows a Server, playing what it announces through a Player.
+type Client struct {
+	base   *url.URL
+	http   *http.Client
+	player Player
+	sounds *remoteFS
+
+	mu      sync.Mutex
+	samples []skewSample
+	status  Status
+	cancel  context.CancelFunc // cancels the play waiting for its start
+}
+
+// NewClient creates a Client for the server at base, such as
+// "http://192.168.1.10:8787".
+func NewClient(base string, player Player) (*Client, error) {
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("parse server address: %w", err)
+	}
+	c := &Client{
+		base:   u,
+		http:   &http.Client{Timeout: 10 * time.Second},
+		player: player,
+	}
+	c.sounds = &remoteFS{client: c, files: make(map[string][]byte)}
+	return c, nil
+}
+
+// Status returns the state of the connection.
+func (c *Client) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+// Run follows the server until ctx is done. If group is not empty,
+// announcements multicast to it are acted on as soon as they arrive;
+// the server is polled either way, which also recovers lost datagrams.
+func (c *Client) Run(ctx context.Context, group string) error {
+	if group != "" {
+		conn, err := listenMulticast(group)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		go c.readMulticast(ctx, conn)
+	}
+
+	backoff := pollInterval
+	for {
+		if err := c.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			c.mu.Lock()
+			c.status.Connected = false
+			c.status.Err = err
+			c.mu.Unlock()
+			backoff = min(backoff*2, maxBackoff)
+		} else {
+			backoff = pollInterval
+		}
+		select {
+		case <-ctx.Done():
+			c.stopPending()
+			return nil
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// poll fetches the server's status, measures its clock and acts on the
+// announcement.
+func (c *Client) poll(ctx context.Context) error {
+	sent := time.Now()
+	var a Announcement
+	if err := c.get(ctx, "/status", func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(&a)
+	}); err != nil {
+		return err
+	}
+	received := time.Now()
+
+	// Assume the server read its clock halfway through the round trip.
+	rtt := received.Sub(sent)
+	sample := skewSample{offset: a.Now.Sub(sent.Add(rtt / 2)), rtt: rtt}
+
+	c.mu.Lock()
+	c.samples = append(c.samples, sample)
+	if len(c.samples) > skewSamples {
+		c.samples = c.samples[1:]
+	}
+	best := c.samples[0]
+	for _, s := range c.samples {
+		if s.rtt < best.rtt {
+			best = s
+		}
+	}
+	c.status.Connected = true
+	c.status.Err = nil
+	c.status.Offset = best.offset
+	c.mu.Unlock()
+
+	c.handle(ctx, a)
+	return nil
+}
+
+// handle acts on an announcement unless it has been seen already.
+func (c *Client) handle(ctx context.Context, a Announcement) {
+	c.mu.Lock()
+	last := c.status.Last
+	if a.Server == last.Server && a.Seq <= last.Seq {
+		c.mu.Unlock()
+		return
+	}
+	if a.Server != last.Server {
+		// The server restarted, perhaps with different sounds.
+		c.sounds.clear()
+	}
+	c.status.Last = a
+	offset := c.status.Offset
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+	var playCtx context.Context
+	if a.Playing {
+		playCtx, c.cancel = context.WithCancel(ctx)
+	}
+	c.mu.Unlock()
+
+	c.player.Stop()
+	if !a.Playing {
+		return
+	}
+	start := a.StartAt.Add(-offset)
+	if time.Since(start) > joinWindow {
+		return
+	}
+	go c.play(playCtx, a.sequence(), start)
+}
+
+// play fetches the sounds of seq, waits until start and plays it.
+func (c *Client) play(ctx context.Context, seq audio.Sequence, start time.Time) {
+	for _, st := range seq {
+		if st.Sound == "" {
+			continue
+		}
+		if _, err := c.sounds.fetch(ctx, st.Sound); err != nil {
+			c.mu.Lock()
+			c.status.Err = err
+			c.mu.Unlock()
+			return
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(time.Until(start)):
+	}
+	c.player.PlaySequence(ctx, c.sounds, seq)
+}
+
+// stopPending cancels a play that has not started yet.
+func (c *Client) stopPending() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+// get fetches a path from the server and passes the body to read.
+func (c *Client) get(ctx context.Context, p string, read func(io.Reader) error) error {
+	u := c.base.JoinPath(p)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("reach server: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: %s", p, resp.Status)
+	}
+	if err := read(resp.Body); err != nil {
+		return fmt.Errorf("get %s: %w", p, err)
+	}
+	return nil
+}
+
+// listenMulticast joins a multicast group given as "address:port".
+func listenMulticast(group string) (*net.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr("udp", group)
+	if err != nil {
+		return nil, fmt.Errorf("multicast group %s: %w", group, err)
+	}
+	conn, err := net.ListenMulticastUDP("udp", nil, addr)
+	if err != nil {
+		return nil, fmt.Errorf("join multicast group %s: %w", group, err)
+	}
+	return conn, nil
+}
+
+// readMulticast acts on announcements from the server until conn is
+// closed. Announcements from other servers on the group are ignored.
+func (c *Client) readMulticast(ctx context.Context, conn *net.UDPConn) {
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		var a Announcement
+		if json.Unmarshal(buf[:n], &a) != nil {
+			continue
+		}
+		c.mu.Lock()
+		ours := a.Server == c.status.Last.Server
+		c.mu.Unlock()
+		if ours {
+			c.handle(ctx, a)
+		}
+	}
+}
+
+// remoteFS is the server's sounds, downloaded as they are needed.
+type remoteFS struct {
+	client *Client
+
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// Open implements fs.FS.
+func (r *remoteFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	data, err := r.fetch(context.Background(), name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return audio.MemFile(name, data), nil
+}
+
+// fetch returns a sound, downloading it the first time.
+func (r *remoteFS) fetch(ctx context.Context, name string) ([]byte, error) {
+	r.mu.Lock()
+	data, ok := r.files[name]
+	r.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+	err := r.client.get(ctx, "/sounds/"+name, func(body io.Reader) error {
+		var err error
+		data, err = io.ReadAll(body)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.files[name] = data
+	r.mu.Unlock()
+	return data, nil
+}
+
+// clear forgets the downloaded sounds.
+func (r *remoteFS) clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.files)
+}
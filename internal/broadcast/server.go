@@ -0,0 +1,176 @@
+package broadcast
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/audio"
+)
+
+// Server publishes what the clock plays. It serves:
+//
+//	/status         the current Announcement as JSON
+//	/sounds/<name>  a sound file
+//	/stream         the sound being played, for any HTTP audio player
+type Server struct {
+	sounds fs.FS
+	id     string
+
+	mu      sync.Mutex
+	current Announcement
+	mcast   net.Conn // nil unless multicasting
+}
+
+// NewServer creates a Server offering the sounds in fsys.
+func NewServer(fsys fs.FS) *Server {
+	b := make([]byte, 8)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	return &Server{sounds: fsys, id: id, current: Announcement{Server: id}}
+}
+
+// Multicast also sends announcements to the UDP multicast group, given as
+// "address:port".
+func (s *Server) Multicast(group string) error {
+	conn, err := net.Dial("udp", group)
+	if err != nil {
+		return fmt.Errorf("multicast to %s: %w", group, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mcast != nil {
+		s.mcast.Close()
+	}
+	s.mcast = conn
+	return nil
+}
+
+// Play announces that seq is about to play, and returns it delayed by Lead
+// so that the server plays in step with its clients.
+func (s *Server) Play(title string, seq audio.Sequence) audio.Sequence {
+	now := time.Now()
+	s.announce(Announcement{Playing: true, Title: title, Steps: stepsOf(seq), StartAt: now.Add(Lead)}, now)
+	return append(audio.Sequence{{Gap: Lead}}, seq...)
+}
+
+// Stop announces that playback has stopped.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	playing := s.current.Playing
+	s.mu.Unlock()
+	if playing {
+		s.announce(Announcement{}, time.Now())
+	}
+}
+
+// Finish marks playback as over once the server has played it through, so
+// that nothing is offered on /stream. Nothing is announced: listening
+// clocks finish on their own.
+func (s *Server) Finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current.Playing = false
+}
+
+// announce makes a the current announcement and multicasts it.
+func (s *Server) announce(a Announcement, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.Server = s.id
+	a.Seq = s.current.Seq + 1
+	s.current = a
+	if s.mcast != nil {
+		a.Now = now
+		data, err := json.Marshal(a)
+		if err == nil {
+			// Clients poll /status too, so a lost datagram only delays them.
+			s.mcast.Write(data)
+		}
+	}
+}
+
+// Close stops multicasting.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mcast == nil {
+		return nil
+	}
+	err := s.mcast.Close()
+	s.mcast = nil
+	return err
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch {
+	case r.URL.Path == "/status":
+		s.mu.Lock()
+		a := s.current
+		s.mu.Unlock()
+		a.Now = time.Now()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(a)
+	case r.URL.Path == "/stream":
+		s.mu.Lock()
+		a := s.current
+		s.mu.Unlock()
+		sound := ""
+		for _, st := range a.Steps {
+			if st.Sound != "" {
+				sound = st.Sound
+				break
+			}
+		}
+		if !a.Playing || sound == "" {
+			http.Error(w, "nothing is playing", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		s.serveSound(w, r, sound)
+	case strings.HasPrefix(r.URL.Path, "/sounds/"):
+		s.serveSound(w, r, strings.TrimPrefix(r.URL.Path, "/sounds/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// contentTypes are the types of the sounds the clock plays, which are not
+// all known to the mime package everywhere.
+var contentTypes = map[string]string{
+	".mp3": "audio/mpeg",
+	".wav": "audio/wav",
+}
+
+// serveSound writes a sound file, supporting range requests so that
+// players can seek.
+func (s *Server) serveSound(w http.ResponseWriter, r *http.Request, name string) {
+	if !fs.ValidPath(name) {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := fs.ReadFile(s.sounds, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if ct := contentTypes[strings.ToLower(path.Ext(name))]; ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
@@ -0,0 +1,87 @@
+package chrono
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func TestFake_After(t *testing.T) {
+	f := NewFake(start)
+	c := f.After(time.Minute)
+	f.Advance(59 * time.Second)
+	select {
+	case <-c:
+		t.Fatal("After fired early")
+	default:
+	}
+	f.Advance(2 * time.Second)
+	select {
+	case at := <-c:
+		if !at.Equal(start.Add(time.Minute)) {
+			t.Errorf("After fired at %v, want %v", at, start.Add(time.Minute))
+		}
+	default:
+		t.Fatal("After did not fire")
+	}
+	if got := f.Since(start); got != 61*time.Second {
+		t.Errorf("Since = %v, want 61s", got)
+	}
+	if f.Waiting() != 0 {
+		t.Error("expected a fired After to be forgotten")
+	}
+}
+
+func TestFake_Ticker(t *testing.T) {
+	f := NewFake(start)
+	tk := f.NewTicker(100 * time.Millisecond)
+	var ticks []time.Time
+	for i := 0; i < 3; i++ {
+		f.Advance(100 * time.Millisecond)
+		ticks = append(ticks, <-tk.C())
+	}
+	if !ticks[2].Equal(start.Add(300 * time.Millisecond)) {
+		t.Errorf("third tick at %v", ticks[2])
+	}
+
+	// Ticks nobody receives are dropped, as with a real ticker.
+	f.Advance(time.Second)
+	<-tk.C()
+	select {
+	case <-tk.C():
+		t.Error("expected dropped ticks")
+	default:
+	}
+
+	tk.Stop()
+	f.Advance(time.Second)
+	select {
+	case <-tk.C():
+		t.Error("expected no ticks after Stop")
+	default:
+	}
+}
+
+func TestFake_Set(t *testing.T) {
+	f := NewFake(start)
+	c := f.After(time.Hour)
+	f.Set(start.Add(24 * time.Hour))
+	if !f.Now().Equal(start.Add(24 * time.Hour)) {
+		t.Errorf("Now = %v after Set", f.Now())
+	}
+	if at := <-c; !at.Equal(start.Add(time.Hour)) {
+		t.Errorf("After fired at %v, want its own time", at)
+	}
+}
+
+func TestSystem(t *testing.T) {
+	before := time.Now()
+	if now := System.Now(); now.Before(before) {
+		t.Errorf("System.Now = %v, before %v", now, before)
+	}
+	tk := System.NewTicker(time.Millisecond)
+	defer tk.Stop()
+	<-tk.C()
+	<-System.After(time.Millisecond)
+}
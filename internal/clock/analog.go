@@ -0,0 +1,62 @@
+package clock
+
+import (
+	"math"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/braille"
+)
+
+// MinAnalogSize is the smallest clock face, in dots, whose hands can still
+// be told apart.
+const MinAnalogSize = 16
+
+// Analog draws a clock face showing t on a Braille canvas size dots across
+// and down: a dial with a tick for each minute and longer ones for the
+// hours, the hour and minute hands and, with seconds, the second hand.
+// Braille dots are about as tall as they are wide, so the face comes out
+// round, size/2 columns by size/4 rows.
+func Analog(t time.Time, size int, seconds bool) string {
+	c := braille.New(size, size)
+	mid := (size - 1) / 2
+	r := float64(mid)
+	c.Circle(mid, mid, mid)
+
+	// at returns the dot a distance of length along the direction a
+	// fraction of a turn clockwise from 12 o'clock.
+	at := func(turn, length float64) (int, int) {
+		a := 2 * math.Pi * turn
+		return mid + int(math.Round(length*math.Sin(a))), mid - int(math.Round(length*math.Cos(a)))
+	}
+	// stroke draws along that direction from one distance to another.
+	stroke := func(turn, from, to float64) {
+		x0, y0 := at(turn, from)
+		x1, y1 := at(turn, to)
+		c.Line(x0, y0, x1, y1)
+	}
+	for m := 0; m < 60; m++ {
+		turn := float64(m) / 60
+		switch {
+		case m%15 == 0:
+			stroke(turn, r*0.75, r-2)
+		case m%5 == 0:
+			stroke(turn, r*0.85, r-2)
+		case size >= 2*MinAnalogSize:
+			c.Set(at(turn, r-2))
+		}
+	}
+
+	secs := float64(t.Second())
+	mins := float64(t.Minute()) + secs/60
+	hours := float64(t.Hour()%12) + mins/60
+	// The hour hand is drawn three dots thick.
+	hx, hy := at(hours/12, r*0.5)
+	for _, d := range [][2]int{{0, 0}, {1, 0}, {0, 1}} {
+		c.Line(mid+d[0], mid+d[1], hx+d[0], hy+d[1])
+	}
+	stroke(mins/60, 0, r*0.8)
+	if seconds {
+		stroke(secs/60, 0, r*0.9)
+	}
+	return c.String()
+}
@@ -0,0 +1,52 @@
+package clock
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// dotAt reports whether the dot at x, y of a Braille drawing is raised.
+func dotAt(drawing string, x, y int) bool {
+	bits := [2][4]rune{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+	cell := []rune(strings.Split(drawing, "\n")[y/4])[x/2]
+	return cell&bits[x%2][y%4] != 0
+}
+
+func TestAnalog(t *testing.T) {
+	const size, mid = 41, 20
+	face := Analog(time.Date(2026, 10, 18, 3, 0, 30, 0, time.UTC), size, true)
+	rows := strings.Split(face, "\n")
+	if len(rows) != (size+3)/4 || len([]rune(rows[0])) != (size+1)/2 {
+		t.Fatalf("expected a face of %d×%d cells, got %d rows of %d", (size+1)/2, (size+3)/4, len(rows), len([]rune(rows[0])))
+	}
+	// The dial runs round the edge.
+	for _, p := range [][2]int{{mid, 0}, {0, mid}, {size - 1, mid}, {mid, size - 1}} {
+		if !dotAt(face, p[0], p[1]) {
+			t.Errorf("expected the dial through %v", p)
+		}
+	}
+	// The hour hand points right, the minute hand up and the second hand
+	// down; nothing points left.
+	for d := 1; d < 8; d++ {
+		if !dotAt(face, mid+d, mid) {
+			t.Errorf("expected the hour hand %d dots right of the center", d)
+		}
+		if !dotAt(face, mid, mid-d) {
+			t.Errorf("expected the minute hand %d dots above the center", d)
+		}
+		if !dotAt(face, mid, mid+d) {
+			t.Errorf("expected the second hand %d dots below the center", d)
+		}
+		if dotAt(face, mid-d-1, mid) {
+			t.Errorf("expected nothing %d dots left of the center", d+1)
+		}
+	}
+
+	if Analog(time.Date(2026, 10, 18, 3, 0, 30, 0, time.UTC), size, false) == face {
+		t.Error("expected the second hand to be left out without seconds")
+	}
+	if Analog(time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC), size, false) != Analog(time.Date(2026, 10, 18, 3, 0, 0, 0, time.UTC), size, false) {
+		t.Error("expected 15:00 and 03:00 to look the same")
+	}
+}
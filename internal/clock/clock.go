@@ -12,7 +12,8 @@ const (
 	ColonWidth = 4
 )
 
-// Segments defines large block-style patterns for digits 0-9.
+// Segments defines large block-style patterns for digits 0-9, and for the
+// letters A, P and M of 12-hour time.
 // Each digit is 7 rows tall and 8 characters wide using █ and spaces.
 var Segments = map[rune][DigitRows]string{
 	'0': {
@@ -105,6 +106,33 @@ var Segments = map[rune][DigitRows]string{
 		"      ██",
 		" ██████ ",
 	},
+	'A': {
+		" ██████ ",
+		"██    ██",
+		"██    ██",
+		"████████",
+		"██    ██",
+		"██    ██",
+		"██    ██",
+	},
+	'P': {
+		"███████ ",
+		"██    ██",
+		"██    ██",
+		"███████ ",
+		"██      ",
+		"██      ",
+		"██      ",
+	},
+	'M': {
+		"██    ██",
+		"███  ███",
+		"████████",
+		"██ ██ ██",
+		"██    ██",
+		"██    ██",
+		"██    ██",
+	},
 }
 
 // ColonOn is the colon separator when visible.
@@ -142,64 +170,87 @@ func RenderDigit(ch rune) [DigitRows]string {
 	return result
 }
 
+// SmallRows is the height of the small digits used for centiseconds.
+const SmallRows = 5
+
+// SmallSegments defines 3-wide, 5-row digits drawn beside the large ones.
+var SmallSegments = map[rune][SmallRows]string{
+	'0': {"███", "█ █", "█ █", "█ █", "███"},
+	'1': {" █ ", "██ ", " █ ", " █ ", "███"},
+	'2': {"███", "  █", "███", "█  ", "███"},
+	'3': {"███", "  █", " ██", "  █", "███"},
+	'4': {"█ █", "█ █", "███", "  █", "  █"},
+	'5': {"███", "█  ", "███", "  █", "███"},
+	'6': {"███", "█  ", "███", "█ █", "███"},
+	'7': {"███", "  █", " █ ", " █ ", " █ "},
+	'8': {"███", "█ █", "███", "█ █", "███"},
+	'9': {"███", "█ █", "███", "  █", "███"},
+}
+
 // RenderTime builds the full ASCII clock string for the given time with seconds.
 // showColon controls whether the colon is displayed (for blinking effect).
 func RenderTime(t time.Time, showColon bool) string {
-	timeStr := fmt.Sprintf("%02d:%02d:%02d", t.Hour(), t.Minute(), t.Second())
+	return RenderTimeFormat(t, showColon, Format{Seconds: true, LeadingZero: true})
+}
 
-	var parts [][DigitRows]string
-	for _, ch := range timeStr {
-		if ch == ':' {
-			if showColon {
-				parts = append(parts, ColonOn)
-			} else {
-				parts = append(parts, ColonOff)
-			}
-		} else {
-			parts = append(parts, RenderDigit(ch))
-		}
-	}
+// Format is how the time of day is laid out.
+type Format struct {
+	TwelveHour  bool // 12-hour time followed by AM or PM
+	Seconds     bool // show the seconds
+	LeadingZero bool // pad hours below 10 with a zero, as in 09:05
+}
 
-	var lines [DigitRows]string
-	for row := 0; row < DigitRows; row++ {
-		var segments []string
-		for _, p := range parts {
-			segments = append(segments, p[row])
-		}
-		lines[row] = strings.Join(segments, "  ")
-	}
+// RenderTimeFormat builds the ASCII clock string for the given time in
+// format f.
+func RenderTimeFormat(t time.Time, showColon bool, f Format) string {
+	return Block.RenderTime(t, showColon, f)
+}
 
-	return strings.Join(lines[:], "\n")
+// TimeText formats the time of day in format f, e.g. "9:05 PM".
+func TimeText(t time.Time, f Format) string {
+	hour := t.Hour()
+	if f.TwelveHour {
+		hour = (hour+11)%12 + 1
+	}
+	text := fmt.Sprintf("%d:%02d", hour, t.Minute())
+	if f.LeadingZero {
+		text = fmt.Sprintf("%02d:%02d", hour, t.Minute())
+	}
+	if f.Seconds {
+		text += fmt.Sprintf(":%02d", t.Second())
+	}
+	if f.TwelveHour {
+		text += " " + t.Format("PM")
+	}
+	return text
 }
 
-// RenderDuration builds the ASCII clock string for a duration (used by stopwatch).
+// RenderDuration builds the ASCII clock string for a duration (used by
+// stopwatch and timer). The layout grows with the duration: MM:SS under an
+// hour, H:MM:SS under a day and D:HH:MM from then on.
 func RenderDuration(d time.Duration, showColon bool) string {
-	total := int(d.Seconds())
-	minutes := total / 60
-	seconds := total % 60
-	timeStr := fmt.Sprintf("%02d:%02d", minutes, seconds)
+	return Block.RenderDuration(d, showColon)
+}
 
-	var parts [][DigitRows]string
-	for _, ch := range timeStr {
-		if ch == ':' {
-			if showColon {
-				parts = append(parts, ColonOn)
-			} else {
-				parts = append(parts, ColonOff)
-			}
-		} else {
-			parts = append(parts, RenderDigit(ch))
-		}
-	}
+// RenderDurationCentis is RenderDuration followed by the centiseconds in
+// small digits. They are left out of the D:HH:MM layout, which does not
+// show seconds either.
+func RenderDurationCentis(d time.Duration, showColon bool) string {
+	return Block.RenderDurationCentis(d, showColon)
+}
 
-	var lines [DigitRows]string
-	for row := 0; row < DigitRows; row++ {
-		var segments []string
-		for _, p := range parts {
-			segments = append(segments, p[row])
-		}
-		lines[row] = strings.Join(segments, "  ")
+// DurationText formats d in the layout for its length, e.g. "1:02:03".
+func DurationText(d time.Duration) string {
+	total := int(max(0, d) / time.Second)
+	seconds := total % 60
+	minutes := total / 60 % 60
+	hours := total / 3600 % 24
+	days := total / 86400
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%d:%02d:%02d", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
 	}
-
-	return strings.Join(lines[:], "\n")
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
 }
@@ -83,3 +83,109 @@ func TestRenderTime_AllDigits(t *testing.T) {
 		}
 	}
 }
+
+func TestDurationText(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "00:00"},
+		{-time.Second, "00:00"},
+		{59*time.Minute + 59*time.Second + 999*time.Millisecond, "59:59"},
+		{time.Hour, "1:00:00"},
+		{23*time.Hour + 5*time.Minute + 9*time.Second, "23:05:09"},
+		{24 * time.Hour, "1:00:00"},
+		{50*time.Hour + 7*time.Minute + 30*time.Second, "2:02:07"},
+	}
+	for _, tt := range tests {
+		if got := DurationText(tt.d); got != tt.want {
+			t.Errorf("DurationText(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestRenderDuration_Layouts(t *testing.T) {
+	width := func(s string) int { return len([]rune(strings.Split(s, "\n")[0])) }
+	mmss := RenderDuration(61*time.Minute-2*time.Minute, true)
+	hmmss := RenderDuration(61*time.Minute, true)
+	if width(hmmss) <= width(mmss) {
+		t.Error("expected the hour layout to be wider than MM:SS")
+	}
+	// An hour past 59:59 must not render as "60:00".
+	if hmmss == Block.RenderText("60:00", true) {
+		t.Error("expected H:MM:SS after an hour")
+	}
+	if a, b := RenderDuration(100*time.Hour, true), RenderDuration(100*time.Hour+59*time.Second, true); a != b {
+		t.Error("expected the day layout to leave out seconds")
+	}
+}
+
+func TestRenderDurationCentis(t *testing.T) {
+	d := 3*time.Minute + 7*time.Second + 420*time.Millisecond
+	out := RenderDurationCentis(d, true)
+	lines := strings.Split(out, "\n")
+	if len(lines) != DigitRows {
+		t.Fatalf("expected %d lines, got %d", DigitRows, len(lines))
+	}
+	plain := strings.Split(RenderDuration(d, true), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, plain[i]) {
+			t.Fatalf("row %d: expected the large digits first", i)
+		}
+		if len([]rune(line)) != len([]rune(lines[0])) {
+			t.Errorf("row %d has a different width", i)
+		}
+	}
+	// The top rows hold no small digits; the bottom row holds the dot and
+	// the bottoms of "4" and "2".
+	if strings.TrimSpace(strings.TrimPrefix(lines[0], plain[0])) != "" {
+		t.Error("expected the small digits to sit at the bottom")
+	}
+	if got := strings.TrimPrefix(lines[DigitRows-1], plain[DigitRows-1]); got != "  █   █ ███" {
+		t.Errorf("bottom row of the centiseconds = %q", got)
+	}
+	if RenderDurationCentis(30*time.Hour, true) != RenderDuration(30*time.Hour, true) {
+		t.Error("expected no centiseconds in the day layout")
+	}
+}
+
+func TestTimeText(t *testing.T) {
+	full := Format{Seconds: true, LeadingZero: true}
+	tests := []struct {
+		h, m, s int
+		f       Format
+		want    string
+	}{
+		{9, 5, 3, full, "09:05:03"},
+		{9, 5, 3, Format{LeadingZero: true}, "09:05"},
+		{9, 5, 3, Format{Seconds: true}, "9:05:03"},
+		{0, 30, 0, Format{TwelveHour: true}, "12:30 AM"},
+		{12, 0, 0, Format{TwelveHour: true}, "12:00 PM"},
+		{21, 5, 9, Format{TwelveHour: true, Seconds: true, LeadingZero: true}, "09:05:09 PM"},
+		{13, 45, 0, Format{TwelveHour: true}, "1:45 PM"},
+	}
+	for _, tt := range tests {
+		tm := time.Date(2025, 1, 1, tt.h, tt.m, tt.s, 0, time.UTC)
+		if got := TimeText(tm, tt.f); got != tt.want {
+			t.Errorf("TimeText(%02d:%02d:%02d, %+v) = %q, want %q", tt.h, tt.m, tt.s, tt.f, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTimeFormat_Meridiem(t *testing.T) {
+	am := RenderTimeFormat(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC), true, Format{TwelveHour: true})
+	pm := RenderTimeFormat(time.Date(2025, 1, 1, 21, 0, 0, 0, time.UTC), true, Format{TwelveHour: true})
+	if am == pm {
+		t.Error("expected AM and PM to be drawn differently")
+	}
+	lines := strings.Split(pm, "\n")
+	if len(lines) != DigitRows {
+		t.Fatalf("expected %d lines, got %d", DigitRows, len(lines))
+	}
+	if !strings.HasSuffix(lines[1], Segments['P'][1]+"  "+Segments['M'][1]) {
+		t.Errorf("expected the PM glyphs at the end, got %q", lines[1])
+	}
+	if RenderTime(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC), true) != RenderTimeFormat(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC), true, Format{Seconds: true, LeadingZero: true}) {
+		t.Error("expected RenderTime to keep its 24-hour layout")
+	}
+}
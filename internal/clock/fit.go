@@ -0,0 +1,92 @@
+package clock
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Size returns how many columns and rows a drawing takes.
+func Size(drawing string) (width, height int) {
+	lines := strings.Split(drawing, "\n")
+	for _, l := range lines {
+		width = max(width, utf8.RuneCountInString(l))
+	}
+	return width, len(lines)
+}
+
+// blocksOnly reports whether a drawing is made of full blocks alone, so
+// that it can be scaled.
+func blocksOnly(drawing string) bool {
+	for _, r := range drawing {
+		if r != '█' && r != ' ' && r != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// Scale enlarges a drawing made of full blocks by factor, a multiple of
+// one half. Half blocks draw the half rows, so each row of the terminal
+// holds two rows of pixels.
+func Scale(drawing string, factor float64) string {
+	var src [][]rune
+	for _, l := range strings.Split(drawing, "\n") {
+		src = append(src, []rune(l))
+	}
+	width, height := Size(drawing)
+	ink := func(x, y int) bool {
+		return y < len(src) && x < len(src[y]) && src[y][x] != ' '
+	}
+
+	cols := int(math.Ceil(float64(width) * factor))
+	halves := int(math.Ceil(float64(height) * 2 * factor))
+	// pixel reports whether the half row y of column x is drawn.
+	pixel := func(x, y int) bool {
+		if y >= halves {
+			return false
+		}
+		return ink(int(float64(x)/factor), int(float64(y)/(2*factor)))
+	}
+	lines := make([]string, (halves+1)/2)
+	for row := range lines {
+		var b strings.Builder
+		for x := 0; x < cols; x++ {
+			top, bottom := pixel(x, 2*row), pixel(x, 2*row+1)
+			switch {
+			case top && bottom:
+				b.WriteRune('█')
+			case top:
+				b.WriteRune('▀')
+			case bottom:
+				b.WriteRune('▄')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		lines[row] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Fit picks how to draw something in a box of width columns and height
+// rows: the first of drawings that fits, scaled up as far as it fits if it
+// is made of full blocks. It returns false if none fits.
+func Fit(drawings []string, width, height int) (string, bool) {
+	for _, d := range drawings {
+		w, h := Size(d)
+		if w > width || h > height {
+			continue
+		}
+		if !blocksOnly(d) {
+			return d, true
+		}
+		// Scale in half steps, the finest that half blocks can draw.
+		factor := math.Floor(min(float64(width)/float64(w), float64(height)/float64(h))*2) / 2
+		if factor > 1 {
+			d = Scale(d, factor)
+		}
+		return d, true
+	}
+	return "", false
+}
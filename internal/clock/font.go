@@ -0,0 +1,392 @@
+package clock
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/braille"
+)
+
+// Font is a set of glyphs for drawing the clock: digits, ':' and '.',
+// and the letters of AM and PM. All of a font's glyphs have the same
+// height.
+type Font struct {
+	Name   string
+	Height int
+	Gap    int               // columns between glyphs
+	Glyphs map[rune][]string // the rows of each glyph
+}
+
+// Glyph returns the rows of ch. Characters the font lacks are left blank,
+// as wide as a digit; a space the font lacks is as wide as a colon.
+func (f *Font) Glyph(ch rune) []string {
+	if g, ok := f.Glyphs[ch]; ok {
+		return g
+	}
+	like := '0'
+	if ch == ' ' {
+		like = ':'
+	}
+	return f.blank(glyphWidth(f.Glyphs[like]))
+}
+
+// blank returns an empty glyph width columns wide.
+func (f *Font) blank(width int) []string {
+	rows := make([]string, f.Height)
+	for i := range rows {
+		rows[i] = strings.Repeat(" ", width)
+	}
+	return rows
+}
+
+// glyphWidth returns the width of a glyph's widest row.
+func glyphWidth(rows []string) int {
+	width := 0
+	for _, r := range rows {
+		width = max(width, utf8.RuneCountInString(r))
+	}
+	return width
+}
+
+// glyphs returns the glyphs for s, leaving colons blank unless showColon
+// is set.
+func (f *Font) glyphs(s string, showColon bool) [][]string {
+	var parts [][]string
+	for _, ch := range s {
+		if ch == ':' && !showColon {
+			parts = append(parts, f.blank(glyphWidth(f.Glyph(':'))))
+			continue
+		}
+		parts = append(parts, f.Glyph(ch))
+	}
+	return parts
+}
+
+// assemble lays glyphs side by side, Gap columns apart, and joins the
+// rows.
+func (f *Font) assemble(parts [][]string) string {
+	lines := make([]string, f.Height)
+	gap := strings.Repeat(" ", f.Gap)
+	for row := range lines {
+		var segments []string
+		for _, p := range parts {
+			segments = append(segments, pad(p[row], glyphWidth(p)))
+		}
+		lines[row] = strings.Join(segments, gap)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pad right-pads s with spaces to width columns.
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// RenderText draws s in the font. showColon controls whether colons are
+// displayed (for blinking effect).
+func (f *Font) RenderText(s string, showColon bool) string {
+	return f.assemble(f.glyphs(s, showColon))
+}
+
+// Width returns how many columns RenderText takes to draw s.
+func (f *Font) Width(s string) int {
+	width := 0
+	for i, p := range f.glyphs(s, true) {
+		if i > 0 {
+			width += f.Gap
+		}
+		width += glyphWidth(p)
+	}
+	return width
+}
+
+// RenderTime draws the time of day in format fm.
+func (f *Font) RenderTime(t time.Time, showColon bool, fm Format) string {
+	return f.RenderText(TimeText(t, fm), showColon)
+}
+
+// RenderDuration draws a duration in the layout for its length: MM:SS
+// under an hour, H:MM:SS under a day and D:HH:MM from then on.
+func (f *Font) RenderDuration(d time.Duration, showColon bool) string {
+	return f.RenderText(DurationText(d), showColon)
+}
+
+// RenderDurationCentis is RenderDuration followed by the centiseconds,
+// in Compact digits aligned with the bottom of taller fonts. They are left
+// out of the D:HH:MM layout, which does not show seconds either.
+func (f *Font) RenderDurationCentis(d time.Duration, showColon bool) string {
+	parts := f.glyphs(DurationText(d), showColon)
+	if d < 24*time.Hour {
+		parts = append(parts, f.centis(max(0, d)))
+	}
+	return f.assemble(parts)
+}
+
+// centis draws ".cc" for the centiseconds of d as a single glyph.
+func (f *Font) centis(d time.Duration) []string {
+	text := fmt.Sprintf(".%02d", d.Milliseconds()/10%100)
+	small := Compact
+	if f.Height <= small.Height {
+		small = f
+	}
+	rows := strings.Split(small.RenderText(text, true), "\n")
+	width := glyphWidth(rows)
+	for len(rows) < f.Height {
+		rows = append([]string{strings.Repeat(" ", width)}, rows...)
+	}
+	return rows
+}
+
+// Block is the default font: 8×7 digits of full blocks.
+var Block = &Font{Name: "block", Height: DigitRows, Gap: 2, Glyphs: blockGlyphs()}
+
+func blockGlyphs() map[rune][]string {
+	glyphs := map[rune][]string{':': ColonOn[:]}
+	for ch, seg := range Segments {
+		glyphs[ch] = append([]string(nil), seg[:]...)
+	}
+	return glyphs
+}
+
+// Compact is a 3×5 font for small terminals.
+var Compact = &Font{Name: "compact", Height: SmallRows, Gap: 1, Glyphs: compactGlyphs()}
+
+func compactGlyphs() map[rune][]string {
+	glyphs := map[rune][]string{
+		':': {" ", "█", " ", "█", " "},
+		'.': {" ", " ", " ", " ", "█"},
+		'A': {"███", "█ █", "███", "█ █", "█ █"},
+		'P': {"███", "█ █", "███", "█  ", "█  "},
+		'M': {"█   █", "██ ██", "█ █ █", "█   █", "█   █"},
+	}
+	for ch, seg := range SmallSegments {
+		glyphs[ch] = append([]string(nil), seg[:]...)
+	}
+	return glyphs
+}
+
+// Seven-segment display segments, as in the usual a to g labelling.
+const (
+	segA = 1 << iota // top
+	segB             // top right
+	segC             // bottom right
+	segD             // bottom
+	segE             // bottom left
+	segF             // top left
+	segG             // middle
+)
+
+var sevenSegments = map[rune]int{
+	'0': segA | segB | segC | segD | segE | segF,
+	'1': segB | segC,
+	'2': segA | segB | segD | segE | segG,
+	'3': segA | segB | segC | segD | segG,
+	'4': segB | segC | segF | segG,
+	'5': segA | segC | segD | segF | segG,
+	'6': segA | segC | segD | segE | segF | segG,
+	'7': segA | segB | segC,
+	'8': segA | segB | segC | segD | segE | segF | segG,
+	'9': segA | segB | segC | segD | segF | segG,
+	'A': segA | segB | segC | segE | segF | segG,
+	'P': segA | segB | segE | segF | segG,
+}
+
+// Segment draws digits as a seven-segment display, with box-drawing lines.
+var Segment = &Font{Name: "segment", Height: 5, Gap: 1, Glyphs: segmentGlyphs()}
+
+func segmentGlyphs() map[rune][]string {
+	glyphs := map[rune][]string{
+		':': {" ", "╻", " ", "╹", " "},
+		'.': {" ", " ", " ", " ", "╻"},
+		'M': {"┏━┳━┓", "┃ ┃ ┃", "┃ ┃ ┃", "┃ ┃ ┃", "╹ ╹ ╹"},
+	}
+	on := func(segs, seg int, s string) string {
+		if segs&seg != 0 {
+			return s
+		}
+		return strings.Repeat(" ", utf8.RuneCountInString(s))
+	}
+	for ch, segs := range sevenSegments {
+		glyphs[ch] = []string{
+			" " + on(segs, segA, "━━") + " ",
+			on(segs, segF, "┃") + "  " + on(segs, segB, "┃"),
+			" " + on(segs, segG, "━━") + " ",
+			on(segs, segE, "┃") + "  " + on(segs, segC, "┃"),
+			" " + on(segs, segD, "━━") + " ",
+		}
+	}
+	return glyphs
+}
+
+// Braille draws the block font at four times the density, two columns
+// and four rows of it to each Braille character.
+var Braille = &Font{Name: "braille", Height: (DigitRows + 3) / 4, Gap: 1, Glyphs: brailleGlyphs()}
+
+func brailleGlyphs() map[rune][]string {
+	glyphs := make(map[rune][]string)
+	for ch, rows := range blockGlyphs() {
+		glyphs[ch] = toBraille(rows)
+	}
+	glyphs['.'] = toBraille([]string{"", "", "", "", "", "", "██"})
+	return glyphs
+}
+
+// toBraille packs the non-blank characters of rows into Braille dots.
+func toBraille(rows []string) []string {
+	c := braille.New(glyphWidth(rows), len(rows))
+	for y, row := range rows {
+		for x, r := range []rune(row) {
+			if r != ' ' {
+				c.Set(x, y)
+			}
+		}
+	}
+	return c.Rows()
+}
+
+// Wide is the block font at double width.
+var Wide = &Font{Name: "wide", Height: DigitRows, Gap: 3, Glyphs: wideGlyphs()}
+
+func wideGlyphs() map[rune][]string {
+	glyphs := make(map[rune][]string)
+	for ch, rows := range blockGlyphs() {
+		wide := make([]string, len(rows))
+		for i, row := range rows {
+			var b strings.Builder
+			for _, r := range row {
+				b.WriteRune(r)
+				b.WriteRune(r)
+			}
+			wide[i] = b.String()
+		}
+		glyphs[ch] = wide
+	}
+	return glyphs
+}
+
+// Fonts are the built-in fonts by name.
+var Fonts = map[string]*Font{
+	Block.Name:   Block,
+	Compact.Name: Compact,
+	Segment.Name: Segment,
+	Braille.Name: Braille,
+	Wide.Name:    Wide,
+}
+
+// FontNames returns the names of the built-in fonts, sorted.
+func FontNames() []string {
+	names := make([]string, 0, len(Fonts))
+	for name := range Fonts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadFont returns the built-in font called name, or else reads the
+// FIGlet font file at that path.
+func LoadFont(name string) (*Font, error) {
+	if f, ok := Fonts[name]; ok {
+		return f, nil
+	}
+	if !strings.HasSuffix(name, ".flf") {
+		return nil, fmt.Errorf("unknown font %q: want one of %s or a .flf file", name, strings.Join(FontNames(), ", "))
+	}
+	return LoadFIGlet(name)
+}
+
+// LoadFIGlet reads a FIGlet font file.
+func LoadFIGlet(path string) (*Font, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("load font: %w", err)
+	}
+	defer file.Close()
+	f, err := ParseFIGlet(file)
+	if err != nil {
+		return nil, fmt.Errorf("load font %s: %w", path, err)
+	}
+	f.Name = path
+	return f, nil
+}
+
+// ParseFIGlet reads a FIGlet font (.flf), keeping the characters the
+// clock draws. Characters are laid out at full width, without smushing.
+func ParseFIGlet(r io.Reader) (*Font, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !sc.Scan() {
+		return nil, fmt.Errorf("empty font")
+	}
+	header := strings.Fields(sc.Text())
+	if len(header) < 6 || !strings.HasPrefix(header[0], "flf2a") || len(header[0]) < 6 {
+		return nil, fmt.Errorf("not a FIGlet font")
+	}
+	hardblank, _ := utf8.DecodeRuneInString(header[0][5:])
+	var height, comments int
+	if _, err := fmt.Sscan(header[1], &height); err != nil || height < 1 {
+		return nil, fmt.Errorf("bad height %q", header[1])
+	}
+	if _, err := fmt.Sscan(header[5], &comments); err != nil || comments < 0 {
+		return nil, fmt.Errorf("bad comment count %q", header[5])
+	}
+	for i := 0; i < comments; i++ {
+		if !sc.Scan() {
+			return nil, fmt.Errorf("truncated comments")
+		}
+	}
+
+	f := &Font{Height: height, Glyphs: make(map[rune][]string)}
+	// The required characters are ASCII 32 to 126, in order.
+	for ch := rune(32); ch <= 126; ch++ {
+		rows := make([]string, height)
+		for i := range rows {
+			if !sc.Scan() {
+				if err := sc.Err(); err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf("truncated at character %q", ch)
+			}
+			rows[i] = strings.ReplaceAll(trimEndmark(sc.Text()), string(hardblank), " ")
+		}
+		if strings.ContainsRune("0123456789:. APM", ch) {
+			f.Glyphs[ch] = rows
+		}
+	}
+	if _, ok := f.Glyphs['0']; !ok {
+		return nil, fmt.Errorf("font has no digits")
+	}
+
+	// Give the digits one width, so that the time does not jump about as
+	// they change.
+	width := 0
+	for ch := '0'; ch <= '9'; ch++ {
+		width = max(width, glyphWidth(f.Glyphs[ch]))
+	}
+	for ch := '0'; ch <= '9'; ch++ {
+		for i, row := range f.Glyphs[ch] {
+			f.Glyphs[ch][i] = pad(row, width)
+		}
+	}
+	return f, nil
+}
+
+// trimEndmark removes the endmark characters that close each line of a
+// FIGlet character.
+func trimEndmark(line string) string {
+	line = strings.TrimRight(line, " \r")
+	if line == "" {
+		return line
+	}
+	mark, _ := utf8.DecodeLastRuneInString(line)
+	return strings.TrimRight(line, string(mark))
+}
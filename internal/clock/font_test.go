@@ -0,0 +1,166 @@
+package clock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFonts_DigitsLineUp(t *testing.T) {
+	for _, name := range FontNames() {
+		f := Fonts[name]
+		width := glyphWidth(f.Glyph('0'))
+		for ch := '0'; ch <= '9'; ch++ {
+			g := f.Glyph(ch)
+			if len(g) != f.Height {
+				t.Errorf("%s %c: %d rows, want %d", name, ch, len(g), f.Height)
+			}
+			for row, line := range g {
+				if n := len([]rune(line)); n != width {
+					t.Errorf("%s %c row %d: width %d, want %d", name, ch, row, n, width)
+				}
+			}
+		}
+		out := f.RenderTime(time.Date(2025, 1, 1, 21, 5, 0, 0, time.UTC), true, Format{TwelveHour: true, Seconds: true})
+		lines := strings.Split(out, "\n")
+		if len(lines) != f.Height {
+			t.Errorf("%s: %d lines, want %d", name, len(lines), f.Height)
+		}
+		if got, want := len([]rune(lines[0])), f.Width("9:05:00 PM"); got != want {
+			t.Errorf("%s: Width = %d, but drew %d columns", name, want, got)
+		}
+	}
+}
+
+func TestSegment(t *testing.T) {
+	want := []string{
+		" ━━ ",
+		"┃  ┃",
+		"    ",
+		"┃  ┃",
+		" ━━ ",
+	}
+	if got := Segment.Glyph('0'); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("seven-segment 0 =\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestBraille(t *testing.T) {
+	// A full 2×4 block sets all eight dots.
+	if got := toBraille([]string{"██", "██", "██", "██"}); got[0] != "⣿" {
+		t.Errorf("toBraille = %q, want ⣿", got)
+	}
+	if got := toBraille([]string{"█ ", "  ", "  ", " █"}); got[0] != string(rune(0x2800|0x01|0x80)) {
+		t.Errorf("toBraille = %q", got)
+	}
+	if Braille.Height != 2 {
+		t.Errorf("Braille.Height = %d, want 2", Braille.Height)
+	}
+}
+
+// testFLF writes a FIGlet font with two-row characters drawn as the
+// character itself, with '$' for hard blanks.
+func testFLF(t *testing.T) string {
+	var b strings.Builder
+	b.WriteString("flf2a$ 2 1 10 0 1\n")
+	b.WriteString("A test font\n")
+	for ch := 32; ch <= 126; ch++ {
+		glyph := string(rune(ch))
+		if ch == '1' {
+			glyph = "$1" // narrower than the other digits once padded
+		}
+		if ch >= '0' && ch <= '9' && ch != '1' {
+			glyph += glyph + glyph
+		}
+		fmt.Fprintf(&b, "%s@\n%s@@\n", glyph, strings.Repeat("_", len([]rune(glyph))))
+	}
+	path := filepath.Join(t.TempDir(), "test.flf")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFIGlet(t *testing.T) {
+	f, err := LoadFont(testFLF(t))
+	if err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if f.Height != 2 {
+		t.Errorf("Height = %d, want 2", f.Height)
+	}
+	if got := f.Glyph('2'); got[0] != "222" || got[1] != "___" {
+		t.Errorf("glyph 2 = %q", got)
+	}
+	if got := f.Glyph('1'); got[0] != " 1 " {
+		t.Errorf("expected the hard blank as a space and digits padded, got %q", got)
+	}
+	if got, want := f.RenderText("12:3", true), " 1 222:333\n__ _______"; got != want {
+		t.Errorf("RenderText = %q, want %q", got, want)
+	}
+}
+
+func TestLoadFont_Errors(t *testing.T) {
+	if _, err := LoadFont("nope"); err == nil || !strings.Contains(err.Error(), "block") {
+		t.Errorf("expected an error listing the fonts, got %v", err)
+	}
+	if _, err := LoadFont(filepath.Join(t.TempDir(), "missing.flf")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if _, err := ParseFIGlet(strings.NewReader("not a font\n")); err == nil {
+		t.Error("expected an error for a bad header")
+	}
+	if _, err := ParseFIGlet(strings.NewReader("flf2a$ 2 1 10 0 0\n @\n @@\n")); err == nil {
+		t.Error("expected an error for a truncated font")
+	}
+	if f, _ := LoadFont("segment"); f != Segment {
+		t.Error("expected LoadFont to return the built-in font")
+	}
+}
+
+func TestScale(t *testing.T) {
+	drawing := "█ \n██"
+	if got := Scale(drawing, 1); got != drawing {
+		t.Errorf("Scale by 1 = %q, want it unchanged", got)
+	}
+	if got, want := Scale(drawing, 2), "██  \n██  \n████\n████"; got != want {
+		t.Errorf("Scale by 2 = %q, want %q", got, want)
+	}
+	// One and a half times two rows is three rows: the middle row is half
+	// of each. Partial columns are drawn whole.
+	if got, want := Scale("█\n ", 1.5), "██\n▀▀\n  "; got != want {
+		t.Errorf("Scale by 1.5 = %q, want %q", got, want)
+	}
+	if w, h := Size(Scale(Block.RenderText("12:34", true), 2.5)); w != int(2.5*float64(Block.Width("12:34"))) || h != 18 {
+		t.Errorf("scaled clock is %d×%d", w, h)
+	}
+}
+
+func TestFit(t *testing.T) {
+	big := Block.RenderText("12:34", true) // 44×7
+	small := Compact.RenderText("12:34", true)
+	segment := Segment.RenderText("12:34", true)
+
+	if got, ok := Fit([]string{big, small}, 44, 7); !ok || got != big {
+		t.Error("expected the first drawing when it fits exactly")
+	}
+	if got, ok := Fit([]string{big, small}, 100, 30); !ok {
+		t.Error("expected a fit")
+	} else if w, h := Size(got); w != 88 || h != 14 {
+		t.Errorf("expected the drawing doubled to 88×14, got %d×%d", w, h)
+	}
+	if got, ok := Fit([]string{big, small}, 40, 20); !ok {
+		t.Error("expected the smaller font when the first is too wide")
+	} else if w, h := Size(got); w != 34 || h != 10 {
+		t.Errorf("expected the 17×5 drawing doubled, got %d×%d", w, h)
+	}
+	if got, ok := Fit([]string{segment}, 100, 30); !ok || got != segment {
+		t.Error("expected a drawing not made of blocks to be left at its size")
+	}
+	if _, ok := Fit([]string{big, small}, 10, 3); ok {
+		t.Error("expected nothing to fit in 10×3")
+	}
+}
@@ -0,0 +1,337 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config holds the user's settings for the clock.
+type Config struct {
+	Audio     Audio     `json:"audio"`
+	Alerts    Alerts    `json:"alerts"`
+	Display   Display   `json:"display"`
+	Pomodoro  Pomodoro  `json:"pomodoro"`
+	Stopwatch Stopwatch `json:"stopwatch"`
+}
+
+// Display configures how the time of day is drawn.
+type Display struct {
+	// TwelveHour shows 12-hour time with AM or PM.
+	TwelveHour bool `json:"twelve_hour"`
+	// Seconds shows the seconds.
+	Seconds bool `json:"seconds"`
+	// LeadingZero pads hours below 10 with a zero, as in 09:05.
+	LeadingZero bool `json:"leading_zero"`
+	// Blink is how long the colons stay on and then off; zero keeps them
+	// on.
+	Blink Duration `json:"blink"`
+}
+
+// Lap export formats.
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+	ExportOff  = "off"
+)
+
+// Stopwatch configures the Stopwatch mode.
+type Stopwatch struct {
+	// Export is how laps are saved when a stopwatch is reset:
+	// ExportCSV, ExportJSON or ExportOff.
+	Export string `json:"export"`
+	// ExportDir is where laps are saved. Relative paths are relative to
+	// the config file; empty means its "laps" directory.
+	ExportDir string `json:"export_dir"`
+	// Exclusive makes starting one stopwatch stop the others, so that
+	// time is only counted for one task at once.
+	Exclusive bool `json:"exclusive"`
+}
+
+// Pomodoro configures the Pomodoro cycle of the Timer mode.
+type Pomodoro struct {
+	Work       Duration `json:"work"`
+	ShortBreak Duration `json:"short_break"`
+	LongBreak  Duration `json:"long_break"`
+	// LongEvery is how many work sessions come before a long break.
+	LongEvery int `json:"long_every"`
+}
+
+// Visual alert modes.
+const (
+	VisualAuto   = "auto"   // only when no audio player can play the azan
+	VisualAlways = "always" // alongside the azan
+	VisualOff    = "off"
+)
+
+// Alerts configures alerts that do not need sound.
+type Alerts struct {
+	// Visual is when to flash a banner, ring the terminal bell and send
+	// terminal notifications at prayer times: VisualAuto, VisualAlways or
+	// VisualOff.
+	Visual string `json:"visual"`
+	// Desktop sends desktop notifications over D-Bus at prayer times, for
+	// reminders and when countdowns finish.
+	Desktop bool `json:"desktop"`
+	// RemindBefore sends a reminder this long before each prayer; zero
+	// means no reminders.
+	RemindBefore Duration `json:"remind_before"`
+}
+
+// Audio configures sound playback.
+type Audio struct {
+	// Players replaces the built-in list of audio players (Unix only).
+	Players []Player `json:"players"`
+	// Volume is the playback level from 0 to 100.
+	Volume int `json:"volume"`
+	// SoundVolumes overrides Volume for individual sounds, keyed by file
+	// name, e.g. {"azan1.mp3": 70}.
+	SoundVolumes map[string]int `json:"sound_volumes"`
+	// Quiet caps the volume during periods of the day.
+	Quiet []QuietPeriod `json:"quiet"`
+	// FadeIn is how long a sound takes to reach its volume.
+	FadeIn Duration `json:"fade_in"`
+	// FadeOut is how long a stopped sound takes to fall silent.
+	FadeOut Duration `json:"fade_out"`
+	// SoundDir holds sounds that are not built in. Relative paths are
+	// relative to the config file; empty means its "sounds" directory.
+	SoundDir string `json:"sound_dir"`
+	// Sequences sets what plays at each prayer, keyed by prayer name such
+	// as "Fajr", or "default" for prayers without their own.
+	Sequences map[string][]Step `json:"sequences"`
+	// Chimes configures the synthesized clock chimes.
+	Chimes Chimes `json:"chimes"`
+}
+
+// Chimes configures the clock chimes.
+type Chimes struct {
+	// Style is "westminster", "beep", or empty for no chimes.
+	Style string `json:"style"`
+	// Quarters chimes every quarter hour, not only on the hour.
+	Quarters bool `json:"quarters"`
+}
+
+// Step is one part of a sequence: a sound, or a pause.
+type Step struct {
+	Sound string   `json:"sound"`
+	Gap   Duration `json:"gap"`
+}
+
+// SequenceFor returns the sequence configured for a prayer, or nil to play
+// just the azan.
+func (a Audio) SequenceFor(prayer string) []Step {
+	if seq, ok := a.Sequences[prayer]; ok {
+		return seq
+	}
+	return a.Sequences["default"]
+}
+
+// QuietPeriod caps the volume between two times of day. To may be earlier
+// than From for a period that spans midnight.
+type QuietPeriod struct {
+	From   string `json:"from"` // "HH:MM"
+	To     string `json:"to"`   // "HH:MM", exclusive
+	Volume int    `json:"volume"`
+}
+
+// contains reports whether the time of day of t falls in the period.
+func (q QuietPeriod) contains(t time.Time) bool {
+	from, _ := parseClock(q.From)
+	to, _ := parseClock(q.To)
+	now := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
+
+// parseClock converts "HH:MM" to minutes since midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// VolumeAt returns the level to play sound at t: its own volume if set, else
+// Volume, capped by any quiet period containing t.
+func (a Audio) VolumeAt(sound string, t time.Time) int {
+	v := a.Volume
+	if sv, ok := a.SoundVolumes[sound]; ok {
+		v = sv
+	}
+	for _, q := range a.Quiet {
+		if q.contains(t) {
+			v = min(v, q.Volume)
+		}
+	}
+	return v
+}
+
+// validate checks the audio settings for values Load cannot catch.
+func (a Audio) validate() error {
+	if err := checkVolume("volume", a.Volume); err != nil {
+		return err
+	}
+	for name, v := range a.SoundVolumes {
+		if err := checkVolume("sound_volumes."+name, v); err != nil {
+			return err
+		}
+	}
+	for _, q := range a.Quiet {
+		if _, err := parseClock(q.From); err != nil {
+			return fmt.Errorf("quiet period: %w", err)
+		}
+		if _, err := parseClock(q.To); err != nil {
+			return fmt.Errorf("quiet period: %w", err)
+		}
+		if err := checkVolume("quiet period volume", q.Volume); err != nil {
+			return err
+		}
+	}
+	if a.FadeIn < 0 || a.FadeOut < 0 {
+		return errors.New("fades must not be negative")
+	}
+	switch a.Chimes.Style {
+	case "", "westminster", "beep":
+	default:
+		return fmt.Errorf("unknown chime style %q", a.Chimes.Style)
+	}
+	for name, seq := range a.Sequences {
+		for i, step := range seq {
+			if (step.Sound == "") == (step.Gap <= 0) {
+				return fmt.Errorf("sequence %s step %d: set either a sound or a positive gap", name, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+func checkVolume(field string, v int) error {
+	if v < 0 || v > 100 {
+		return fmt.Errorf("%s must be between 0 and 100, got %d", field, v)
+	}
+	return nil
+}
+
+// Duration is a time.Duration written in JSON as a string such as "1.5s".
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"2s\": %w", err)
+	}
+	v, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Player is a user-defined audio player command.
+type Player struct {
+	// Command is the program and its arguments, e.g. "mpv --volume=60 -".
+	// "{file}" is replaced by the path of the sound; without it the sound
+	// is written to the player's standard input.
+	Command string `json:"command"`
+	// Formats lists what the player can decode, e.g. ["wav"]. Empty means
+	// MP3 and WAV.
+	Formats []string `json:"formats"`
+}
+
+// Default returns the settings used when there is no config file.
+func Default() *Config {
+	return &Config{
+		Audio: Audio{
+			Volume:  100,
+			FadeOut: Duration(time.Second),
+		},
+		Alerts:  Alerts{Visual: VisualAuto, Desktop: true},
+		Display: Display{Seconds: true, LeadingZero: true, Blink: Duration(500 * time.Millisecond)},
+		Pomodoro: Pomodoro{
+			Work:       Duration(25 * time.Minute),
+			ShortBreak: Duration(5 * time.Minute),
+			LongBreak:  Duration(15 * time.Minute),
+			LongEvery:  4,
+		},
+		Stopwatch: Stopwatch{Export: ExportCSV},
+	}
+}
+
+// DefaultPath returns the config file location, e.g.
+// ~/.config/my-clock/config.json on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "my-clock", "config.json"), nil
+}
+
+// resolveDir makes dir absolute, relative to the config file at path. An
+// empty dir means def.
+func resolveDir(path, dir, def string) string {
+	if dir == "" {
+		dir = def
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(filepath.Dir(path), dir)
+}
+
+// Load reads the config file at path. A missing file yields the defaults.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		cfg.Audio.SoundDir = resolveDir(path, "", "sounds")
+		cfg.Stopwatch.ExportDir = resolveDir(path, "", "laps")
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if err := cfg.Audio.validate(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	switch cfg.Alerts.Visual {
+	case VisualAuto, VisualAlways, VisualOff:
+	default:
+		return nil, fmt.Errorf("config %s: unknown visual alert mode %q", path, cfg.Alerts.Visual)
+	}
+	if cfg.Alerts.RemindBefore < 0 {
+		return nil, fmt.Errorf("config %s: remind_before must not be negative", path)
+	}
+	if cfg.Display.Blink < 0 {
+		return nil, fmt.Errorf("config %s: blink must not be negative", path)
+	}
+	if p := cfg.Pomodoro; p.Work <= 0 || p.ShortBreak <= 0 || p.LongBreak <= 0 || p.LongEvery < 1 {
+		return nil, fmt.Errorf("config %s: pomodoro durations and long_every must be positive", path)
+	}
+	switch cfg.Stopwatch.Export {
+	case ExportCSV, ExportJSON, ExportOff:
+	default:
+		return nil, fmt.Errorf("config %s: unknown lap export format %q", path, cfg.Stopwatch.Export)
+	}
+	cfg.Audio.SoundDir = resolveDir(path, cfg.Audio.SoundDir, "sounds")
+	cfg.Stopwatch.ExportDir = resolveDir(path, cfg.Stopwatch.ExportDir, "laps")
+	return cfg, nil
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Audio.Players) != 0 {
+		t.Errorf("expected no players by default, got %v", cfg.Audio.Players)
+	}
+	if cfg.Alerts.Visual != VisualAuto {
+		t.Errorf("expected automatic visual alerts by default, got %q", cfg.Alerts.Visual)
+	}
+	if !cfg.Alerts.Desktop || cfg.Alerts.RemindBefore != 0 {
+		t.Errorf("expected desktop notifications without reminders by default, got %+v", cfg.Alerts)
+	}
+}
+
+func TestLoad_Pomodoro(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"pomodoro": {"work": "50m"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	p := cfg.Pomodoro
+	if time.Duration(p.Work) != 50*time.Minute || time.Duration(p.ShortBreak) != 5*time.Minute || p.LongEvery != 4 {
+		t.Errorf("expected the work length to be replaced and the rest kept, got %+v", p)
+	}
+}
+
+func TestLoad_Stopwatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Stopwatch.Export != ExportCSV || cfg.Stopwatch.ExportDir != filepath.Join(dir, "laps") || cfg.Stopwatch.Exclusive {
+		t.Errorf("unexpected defaults %+v", cfg.Stopwatch)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"stopwatch": {"export": "json", "export_dir": "runs", "exclusive": true}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Stopwatch.Export != ExportJSON || cfg.Stopwatch.ExportDir != filepath.Join(dir, "runs") || !cfg.Stopwatch.Exclusive {
+		t.Errorf("unexpected settings %+v", cfg.Stopwatch)
+	}
+}
+
+func TestLoad_Alerts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"alerts": {"desktop": false, "remind_before": "10m"}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Alerts.Desktop || time.Duration(cfg.Alerts.RemindBefore) != 10*time.Minute || cfg.Alerts.Visual != VisualAuto {
+		t.Errorf("unexpected alerts %+v", cfg.Alerts)
+	}
+}
+
+func TestLoad_Display(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if d := cfg.Display; d.TwelveHour || !d.Seconds || !d.LeadingZero || time.Duration(d.Blink) != 500*time.Millisecond {
+		t.Errorf("unexpected defaults %+v", d)
+	}
+
+	data := `{"display": {"twelve_hour": true, "seconds": false, "leading_zero": false, "blink": "1s"}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if d := cfg.Display; !d.TwelveHour || d.Seconds || d.LeadingZero || time.Duration(d.Blink) != time.Second {
+		t.Errorf("unexpected settings %+v", d)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"display": {"blink": "-1s"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for a negative blink")
+	}
+}
+
+func TestLoad_Players(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"audio": {"players": [
+		{"command": "mpv --volume=60 -"},
+		{"command": "aplay -q {file}", "formats": ["wav"]}
+	]}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Audio.Players) != 2 {
+		t.Fatalf("expected 2 players, got %d", len(cfg.Audio.Players))
+	}
+	if got := cfg.Audio.Players[1]; got.Command != "aplay -q {file}" || len(got.Formats) != 1 || got.Formats[0] != "wav" {
+		t.Errorf("unexpected second player: %+v", got)
+	}
+}
+
+func TestLoad_InvalidJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for invalid JSON, got nil")
+	}
+}
+
+func TestLoad_Volume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"audio": {
+		"volume": 80,
+		"sound_volumes": {"dua.mp3": 60},
+		"quiet": [{"from": "22:00", "to": "06:30", "volume": 30}],
+		"fade_in": "3s"
+	}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Audio.FadeIn != Duration(3*time.Second) {
+		t.Errorf("expected 3s fade-in, got %v", time.Duration(cfg.Audio.FadeIn))
+	}
+	if cfg.Audio.FadeOut != Duration(time.Second) {
+		t.Errorf("expected default fade-out to be kept, got %v", time.Duration(cfg.Audio.FadeOut))
+	}
+
+	at := func(clock string) time.Time {
+		tm, err := time.Parse("15:04", clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	tests := []struct {
+		sound string
+		clock string
+		want  int
+	}{
+		{"azan.mp3", "12:00", 80},
+		{"dua.mp3", "12:00", 60},
+		{"azan.mp3", "23:15", 30},
+		{"azan.mp3", "05:00", 30},
+		{"azan.mp3", "06:30", 80},
+	}
+	for _, tc := range tests {
+		if got := cfg.Audio.VolumeAt(tc.sound, at(tc.clock)); got != tc.want {
+			t.Errorf("VolumeAt(%s, %s) = %d, want %d", tc.sound, tc.clock, got, tc.want)
+		}
+	}
+}
+
+func TestLoad_InvalidVolume(t *testing.T) {
+	for _, data := range []string{
+		`{"audio": {"volume": 120}}`,
+		`{"audio": {"quiet": [{"from": "25:00", "to": "06:00", "volume": 10}]}}`,
+		`{"audio": {"fade_out": "soon"}}`,
+		`{"audio": {"chimes": {"style": "cuckoo"}}}`,
+		`{"alerts": {"visual": "sometimes"}}`,
+		`{"alerts": {"remind_before": "-5m"}}`,
+		`{"pomodoro": {"work": "0s"}}`,
+		`{"stopwatch": {"export": "xml"}}`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("expected error for %s, got nil", data)
+		}
+	}
+}
+
+func TestLoad_Sequences(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	data := `{"audio": {"sequences": {
+		"Fajr": [{"sound": "azan_fajr.mp3"}, {"gap": "3s"}, {"sound": "dua.mp3"}],
+		"default": [{"sound": "azan1.mp3"}, {"gap": "3s"}, {"sound": "dua.mp3"}]
+	}}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if want := filepath.Join(dir, "sounds"); cfg.Audio.SoundDir != want {
+		t.Errorf("expected sound dir %s, got %s", want, cfg.Audio.SoundDir)
+	}
+	fajr := cfg.Audio.SequenceFor("Fajr")
+	if len(fajr) != 3 || fajr[0].Sound != "azan_fajr.mp3" || fajr[1].Gap != Duration(3*time.Second) {
+		t.Errorf("unexpected Fajr sequence: %+v", fajr)
+	}
+	if asr := cfg.Audio.SequenceFor("Asr"); len(asr) != 3 || asr[0].Sound != "azan1.mp3" {
+		t.Errorf("expected Asr to use the default sequence, got %+v", asr)
+	}
+	if seq := Default().Audio.SequenceFor("Asr"); seq != nil {
+		t.Errorf("expected no sequence by default, got %+v", seq)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"audio": {"sequences": {"Isha": [{"sound": "a.mp3", "gap": "1s"}]}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for a step with both a sound and a gap")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
 )
 
 // SystemInfo holds system metrics for display.
@@ -116,8 +118,9 @@ func GetMemInfo() (total, used uint64, err error) {
 	return memTotal, memTotal - memAvailable, nil
 }
 
-// GetCPUUsage samples /proc/stat to compute overall CPU usage over a duration.
-func GetCPUUsage(sampleDuration time.Duration) (float64, error) {
+// GetCPUUsage samples /proc/stat to compute overall CPU usage over a duration,
+// as measured by clock.
+func GetCPUUsage(clock chrono.Clock, sampleDuration time.Duration) (float64, error) {
 	read := func() (idle, total uint64, err error) {
 		file, err := os.Open("/proc/stat")
 		if err != nil {
@@ -157,7 +160,7 @@ func GetCPUUsage(sampleDuration time.Duration) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
-	time.Sleep(sampleDuration)
+	<-clock.After(sampleDuration)
 	idle2, total2, err := read()
 	if err != nil {
 		return 0, err
@@ -172,10 +175,10 @@ func GetCPUUsage(sampleDuration time.Duration) (float64, error) {
 	return (1.0 - idleDelta/totalDelta) * 100.0, nil
 }
 
-// Collect gathers current system information.
-func Collect(cpuSampleDuration time.Duration) SystemInfo {
+// Collect gathers current system information, reading the time from clock.
+func Collect(clock chrono.Clock, cpuSampleDuration time.Duration) SystemInfo {
 	info := SystemInfo{
-		DateTime:   time.Now().Format("2006-01-02 15:04:05"),
+		DateTime:   clock.Now().Format("2006-01-02 15:04:05"),
 		GoRoutines: runtime.NumGoroutine(),
 		NumCPU:     runtime.NumCPU(),
 		OS:         runtime.GOOS,
@@ -190,7 +193,7 @@ func Collect(cpuSampleDuration time.Duration) SystemInfo {
 		}
 	}
 
-	if cpu, err := GetCPUUsage(cpuSampleDuration); err == nil {
+	if cpu, err := GetCPUUsage(clock, cpuSampleDuration); err == nil {
 		info.CPUUsage = cpu
 	}
 
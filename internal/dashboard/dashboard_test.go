@@ -1,8 +1,12 @@
 package dashboard
 
 import (
+	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
 )
 
 func TestFormatBytes(t *testing.T) {
@@ -102,3 +106,24 @@ func TestRender(t *testing.T) {
 		t.Error("expected memory percentage in output")
 	}
 }
+
+func TestCollect_FakeClock(t *testing.T) {
+	clk := chrono.NewFake(time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC))
+	done := make(chan SystemInfo)
+	go func() { done <- Collect(clk, time.Second) }()
+	for {
+		select {
+		case info := <-done:
+			if info.DateTime != "2026-10-18 09:30:00" {
+				t.Errorf("DateTime = %q, want the fake clock's time", info.DateTime)
+			}
+			return
+		default:
+			// Let the CPU sample's wait finish without sleeping.
+			if clk.Waiting() > 0 {
+				clk.Advance(time.Second)
+			}
+			runtime.Gosched()
+		}
+	}
+}
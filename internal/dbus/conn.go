@@ -0,0 +1,345 @@
+// Package dbus is a minimal D-Bus client: enough to call methods, emit and
+// receive signals, and answer method calls on the session bus.
+package dbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The message bus itself.
+const (
+	busName      = "org.freedesktop.DBus"
+	busPath      = ObjectPath("/org/freedesktop/DBus")
+	busInterface = "org.freedesktop.DBus"
+)
+
+// handshakeTimeout bounds how long the bus may take to authenticate us and
+// answer our hello.
+var handshakeTimeout = 5 * time.Second
+
+// ErrNoSessionBus is returned by SessionBus when no session bus address is
+// known.
+var ErrNoSessionBus = errors.New("dbus: no session bus address")
+
+// ErrClosed is returned for calls on a closed connection.
+var ErrClosed = errors.New("dbus: connection closed")
+
+// incomingBuffer is how many signals and method calls are queued for
+// Incoming before further ones are dropped.
+const incomingBuffer = 64
+
+// Conn is a connection to a message bus. It is safe for concurrent use.
+type Conn struct {
+	conn     net.Conn
+	name     string // unique name assigned by the bus
+	incoming chan *Message
+
+	wmu sync.Mutex // serializes writes
+
+	mu     sync.Mutex
+	serial uint32
+	calls  map[uint32]chan *Message
+	err    error // set once the connection has failed
+	done   chan struct{}
+}
+
+// SessionBus connects to the user's session bus, from
+// $DBUS_SESSION_BUS_ADDRESS or else $XDG_RUNTIME_DIR/bus.
+func SessionBus() (*Conn, error) {
+	addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if addr == "" {
+		dir := os.Getenv("XDG_RUNTIME_DIR")
+		if dir == "" {
+			return nil, ErrNoSessionBus
+		}
+		path := filepath.Join(dir, "bus")
+		if _, err := os.Stat(path); err != nil {
+			return nil, ErrNoSessionBus
+		}
+		addr = "unix:path=" + path
+	}
+	return Dial(addr)
+}
+
+// Dial connects to the bus at a D-Bus address such as
+// "unix:path=/run/user/1000/bus", authenticates and registers with it.
+// Addresses separated by ';' are tried in turn.
+func Dial(address string) (*Conn, error) {
+	var firstErr error
+	for _, a := range strings.Split(address, ";") {
+		if a == "" {
+			continue
+		}
+		nc, err := dialAddress(a)
+		if err == nil {
+			return newConn(nc)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("dbus: empty address %q", address)
+	}
+	return nil, firstErr
+}
+
+// dialAddress opens the transport for a single address.
+func dialAddress(address string) (net.Conn, error) {
+	transport, rest, ok := strings.Cut(address, ":")
+	if !ok {
+		return nil, fmt.Errorf("dbus: bad address %q", address)
+	}
+	params := make(map[string]string)
+	for _, kv := range strings.Split(rest, ",") {
+		k, v, _ := strings.Cut(kv, "=")
+		unescaped, err := url.PathUnescape(v)
+		if err != nil {
+			return nil, fmt.Errorf("dbus: bad address %q: %w", address, err)
+		}
+		params[k] = unescaped
+	}
+	switch transport {
+	case "unix":
+		switch {
+		case params["path"] != "":
+			return net.Dial("unix", params["path"])
+		case params["abstract"] != "":
+			return net.Dial("unix", "@"+params["abstract"])
+		}
+	case "tcp":
+		return net.Dial("tcp", net.JoinHostPort(params["host"], params["port"]))
+	}
+	return nil, fmt.Errorf("dbus: unsupported address %q", address)
+}
+
+// newConn authenticates over nc and says hello to the bus.
+func newConn(nc net.Conn) (*Conn, error) {
+	r := bufio.NewReader(nc)
+	nc.SetDeadline(time.Now().Add(handshakeTimeout))
+	if err := authenticate(nc, r); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	nc.SetDeadline(time.Time{})
+	c := &Conn{
+		conn:     nc,
+		incoming: make(chan *Message, incomingBuffer),
+		calls:    make(map[uint32]chan *Message),
+		done:     make(chan struct{}),
+	}
+	go c.readLoop(r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	reply, err := c.Call(ctx, busName, busPath, busInterface, "Hello")
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("dbus: hello: %w", err)
+	}
+	if len(reply) > 0 {
+		c.name, _ = reply[0].(string)
+	}
+	return c, nil
+}
+
+// authenticate performs the EXTERNAL authentication handshake, which proves
+// our identity through the socket's credentials.
+func authenticate(nc net.Conn, r *bufio.Reader) error {
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := nc.Write([]byte("\x00AUTH EXTERNAL " + uid + "\r\n")); err != nil {
+		return fmt.Errorf("dbus: auth: %w", err)
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("dbus: auth: %w", err)
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("dbus: auth rejected: %s", strings.TrimSpace(line))
+	}
+	if _, err := nc.Write([]byte("BEGIN\r\n")); err != nil {
+		return fmt.Errorf("dbus: auth: %w", err)
+	}
+	return nil
+}
+
+// readLoop delivers replies to waiting calls and everything else to
+// Incoming, until the connection fails.
+func (c *Conn) readLoop(r *bufio.Reader) {
+	defer close(c.incoming)
+	for {
+		m, err := readMessage(r)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch m.Type {
+		case TypeMethodReturn, TypeError:
+			c.mu.Lock()
+			ch := c.calls[m.ReplySerial]
+			delete(c.calls, m.ReplySerial)
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		default:
+			select {
+			case c.incoming <- m:
+			default:
+			}
+		}
+	}
+}
+
+// fail records why the connection ended and wakes every waiting call.
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
+
+// Name returns the unique name the bus gave this connection.
+func (c *Conn) Name() string {
+	return c.name
+}
+
+// Incoming returns signals, and method calls addressed to this connection.
+// It is closed when the connection ends. Messages are dropped if nobody is
+// receiving.
+func (c *Conn) Incoming() <-chan *Message {
+	return c.incoming
+}
+
+// send assigns m a serial and writes it. If reply is set, the reply to m is
+// delivered to it.
+func (c *Conn) send(m *Message, reply chan *Message) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.serial++
+	m.Serial = c.serial
+	if reply != nil {
+		c.calls[m.Serial] = reply
+	}
+	c.mu.Unlock()
+
+	data, err := m.encode()
+	if err == nil {
+		c.wmu.Lock()
+		_, err = c.conn.Write(data)
+		c.wmu.Unlock()
+	}
+	if err != nil && reply != nil {
+		c.mu.Lock()
+		delete(c.calls, m.Serial)
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// Call calls a method and waits for its reply, returning the reply's body.
+// An error reply is returned as an *Error.
+func (c *Conn) Call(ctx context.Context, dest string, path ObjectPath, iface, member string, args ...any) ([]any, error) {
+	m := &Message{
+		Type:        TypeMethodCall,
+		Path:        path,
+		Interface:   iface,
+		Member:      member,
+		Destination: dest,
+		Body:        args,
+	}
+	reply := make(chan *Message, 1)
+	if err := c.send(m, reply); err != nil {
+		return nil, err
+	}
+	select {
+	case r := <-reply:
+		if r.Type == TypeError {
+			return nil, errorFrom(r)
+		}
+		return r.Body, nil
+	case <-c.done:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.calls, m.Serial)
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// Emit broadcasts a signal.
+func (c *Conn) Emit(path ObjectPath, iface, member string, args ...any) error {
+	return c.send(&Message{Type: TypeSignal, Path: path, Interface: iface, Member: member, Body: args}, nil)
+}
+
+// Reply answers a method call received from Incoming.
+func (c *Conn) Reply(call *Message, args ...any) error {
+	if call.Flags&FlagNoReplyExpected != 0 {
+		return nil
+	}
+	return c.send(&Message{
+		Type:        TypeMethodReturn,
+		ReplySerial: call.Serial,
+		Destination: call.Sender,
+		Body:        args,
+	}, nil)
+}
+
+// ReplyError answers a method call with an error.
+func (c *Conn) ReplyError(call *Message, name, message string) error {
+	return c.send(&Message{
+		Type:        TypeError,
+		ErrorName:   name,
+		ReplySerial: call.Serial,
+		Destination: call.Sender,
+		Body:        []any{message},
+	}, nil)
+}
+
+// AddMatch asks the bus to send this connection the signals matching rule,
+// e.g. "type='signal',interface='org.freedesktop.Notifications'".
+func (c *Conn) AddMatch(ctx context.Context, rule string) error {
+	_, err := c.Call(ctx, busName, busPath, busInterface, "AddMatch", rule)
+	return err
+}
+
+// RequestName asks the bus for a well-known name, failing if another
+// connection already owns it.
+func (c *Conn) RequestName(ctx context.Context, name string) error {
+	const doNotQueue = 4
+	reply, err := c.Call(ctx, busName, busPath, busInterface, "RequestName", name, uint32(doNotQueue))
+	if err != nil {
+		return err
+	}
+	const primaryOwner, alreadyOwner = 1, 4
+	if len(reply) == 1 && (reply[0] == uint32(primaryOwner) || reply[0] == uint32(alreadyOwner)) {
+		return nil
+	}
+	return fmt.Errorf("dbus: name %s is taken", name)
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	err := c.conn.Close()
+	c.fail(ErrClosed)
+	return err
+}
@@ -0,0 +1,229 @@
+package dbus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/dbus/dbustest"
+)
+
+func TestSignatureOf(t *testing.T) {
+	tests := []struct {
+		v    any
+		want Signature
+	}{
+		{"hi", "s"},
+		{uint32(1), "u"},
+		{int32(-1), "i"},
+		{[]string{"a"}, "as"},
+		{map[string]Variant{}, "a{sv}"},
+		{ObjectPath("/"), "o"},
+		{struct {
+			A byte
+			B []int64
+		}{}, "(yax)"},
+	}
+	for _, tt := range tests {
+		got, err := SignatureOf(tt.v)
+		if err != nil || got != tt.want {
+			t.Errorf("SignatureOf(%T) = %q, %v; want %q", tt.v, got, err, tt.want)
+		}
+	}
+	if _, err := SignatureOf(3); err == nil {
+		t.Error("expected int to be rejected, since its size is not fixed")
+	}
+}
+
+func TestMessage_RoundTrip(t *testing.T) {
+	in := &Message{
+		Type:        TypeMethodCall,
+		Serial:      7,
+		Path:        "/org/freedesktop/Notifications",
+		Interface:   "org.freedesktop.Notifications",
+		Member:      "Notify",
+		Destination: "org.freedesktop.Notifications",
+		Body: []any{
+			"clock", uint32(0), "", "Azan", "Time for Fajr",
+			[]string{"stop", "Stop azan"},
+			map[string]Variant{"urgency": {byte(2)}, "transient": {true}},
+			int32(-1),
+		},
+	}
+	data, err := in.encode()
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	out, err := readMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readMessage failed: %v", err)
+	}
+	if out.Signature != "susssasa{sv}i" {
+		t.Errorf("signature = %q", out.Signature)
+	}
+	if out.Member != in.Member || out.Path != in.Path || out.Serial != 7 || out.Destination != in.Destination {
+		t.Errorf("header mismatch: %+v", out)
+	}
+	want := []any{
+		"clock", uint32(0), "", "Azan", "Time for Fajr",
+		[]any{"stop", "Stop azan"},
+		map[any]any{"urgency": Variant{byte(2)}, "transient": Variant{true}},
+		int32(-1),
+	}
+	if !reflect.DeepEqual(out.Body, want) {
+		t.Errorf("body = %#v, want %#v", out.Body, want)
+	}
+}
+
+func TestReadMessage_Truncated(t *testing.T) {
+	data, err := (&Message{Type: TypeSignal, Path: "/", Interface: "a.b", Member: "C", Body: []any{"x"}}).encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readMessage(bytes.NewReader(data[:len(data)-3])); err == nil {
+		t.Error("expected an error for a truncated message")
+	}
+}
+
+func dial(t *testing.T, addr string) *Conn {
+	t.Helper()
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestConn_CallAndSignal(t *testing.T) {
+	addr := dbustest.StartBus(t)
+	server, client := dial(t, addr), dial(t, addr)
+	if server.Name() == "" || server.Name() == client.Name() {
+		t.Fatalf("expected distinct unique names, got %q and %q", server.Name(), client.Name())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.RequestName(ctx, "org.example.Echo"); err != nil {
+		t.Fatalf("RequestName failed: %v", err)
+	}
+	go func() {
+		for m := range server.Incoming() {
+			if m.Type != TypeMethodCall {
+				continue
+			}
+			if m.Member == "Echo" {
+				server.Reply(m, m.Body...)
+			} else {
+				server.ReplyError(m, "org.example.Error.Unknown", "no such method")
+			}
+		}
+	}()
+
+	got, err := client.Call(ctx, "org.example.Echo", "/", "org.example.Echo", "Echo", "hello", uint32(3))
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []any{"hello", uint32(3)}) {
+		t.Errorf("Echo returned %v", got)
+	}
+
+	_, err = client.Call(ctx, "org.example.Echo", "/", "org.example.Echo", "Missing")
+	var dbusErr *Error
+	if !errors.As(err, &dbusErr) || dbusErr.Name != "org.example.Error.Unknown" {
+		t.Errorf("expected an error reply, got %v", err)
+	}
+
+	if err := client.AddMatch(ctx, "type='signal',interface='org.example.Echo'"); err != nil {
+		t.Fatalf("AddMatch failed: %v", err)
+	}
+	if err := server.Emit("/", "org.example.Echo", "Ping", uint32(42)); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+	for {
+		select {
+		case m := <-client.Incoming():
+			if m.Type == TypeSignal && m.Member == "Ping" {
+				if m.Sender != server.Name() || !reflect.DeepEqual(m.Body, []any{uint32(42)}) {
+					t.Errorf("unexpected signal %+v", m)
+				}
+				return
+			}
+		case <-ctx.Done():
+			t.Fatal("signal not received")
+		}
+	}
+}
+
+func TestConn_Closed(t *testing.T) {
+	c := dial(t, dbustest.StartBus(t))
+	c.Close()
+	if _, err := c.Call(context.Background(), busName, busPath, busInterface, "GetId"); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-c.Incoming():
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected Incoming to be closed")
+		}
+	}
+}
+
+func TestDial_SilentBus(t *testing.T) {
+	defer func(d time.Duration) { handshakeTimeout = d }(handshakeTimeout)
+	handshakeTimeout = 100 * time.Millisecond
+
+	// One bus never answers; the other authenticates us and then says
+	// nothing.
+	for _, answer := range []string{"", "OK 0123456789abcdef\r\n"} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		go func() {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer nc.Close()
+			bufio.NewReader(nc).ReadString('\n')
+			nc.Write([]byte(answer))
+			io.Copy(io.Discard, nc)
+		}()
+
+		host, port, _ := net.SplitHostPort(ln.Addr().String())
+		done := make(chan error, 1)
+		go func() {
+			_, err := Dial("tcp:host=" + host + ",port=" + port)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Errorf("expected Dial to fail when the bus answers %q", answer)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Dial still waiting on a bus that answers %q", answer)
+		}
+	}
+}
+
+func TestDial_BadAddress(t *testing.T) {
+	for _, addr := range []string{"", "nonsense", "launchd:env=X"} {
+		if _, err := Dial(addr); err == nil {
+			t.Errorf("Dial(%q) succeeded", addr)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package dbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ObjectPath is a D-Bus object path such as "/org/freedesktop/Notifications".
+type ObjectPath string
+
+// Signature is a D-Bus type signature such as "a{sv}".
+type Signature string
+
+// Variant is a value sent together with its type. The type is worked out
+// from Value when encoding.
+type Variant struct {
+	Value any
+}
+
+var (
+	objectPathType = reflect.TypeOf(ObjectPath(""))
+	signatureType  = reflect.TypeOf(Signature(""))
+	variantType    = reflect.TypeOf(Variant{})
+)
+
+// SignatureOf returns the D-Bus signature of a Go value. Supported types
+// are byte, bool, int16, uint16, int32, uint32, int64, uint64, float64,
+// string, ObjectPath, Signature, Variant, and slices, maps and structs of
+// them.
+func SignatureOf(v any) (Signature, error) {
+	if v == nil {
+		return "", errors.New("dbus: cannot encode nil")
+	}
+	s, err := signatureOfType(reflect.TypeOf(v))
+	return Signature(s), err
+}
+
+func signatureOfType(t reflect.Type) (string, error) {
+	switch t {
+	case objectPathType:
+		return "o", nil
+	case signatureType:
+		return "g", nil
+	case variantType:
+		return "v", nil
+	}
+	switch t.Kind() {
+	case reflect.Uint8:
+		return "y", nil
+	case reflect.Bool:
+		return "b", nil
+	case reflect.Int16:
+		return "n", nil
+	case reflect.Uint16:
+		return "q", nil
+	case reflect.Int32:
+		return "i", nil
+	case reflect.Uint32:
+		return "u", nil
+	case reflect.Int64:
+		return "x", nil
+	case reflect.Uint64:
+		return "t", nil
+	case reflect.Float64:
+		return "d", nil
+	case reflect.String:
+		return "s", nil
+	case reflect.Slice, reflect.Array:
+		elem, err := signatureOfType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "a" + elem, nil
+	case reflect.Map:
+		key, err := signatureOfType(t.Key())
+		if err != nil {
+			return "", err
+		}
+		if !strings.Contains("ybnqiuxtdsog", key) {
+			return "", fmt.Errorf("dbus: map key %s is not a basic type", t.Key())
+		}
+		val, err := signatureOfType(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "a{" + key + val + "}", nil
+	case reflect.Struct:
+		var b strings.Builder
+		b.WriteByte('(')
+		for i := 0; i < t.NumField(); i++ {
+			f, err := signatureOfType(t.Field(i).Type)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(f)
+		}
+		b.WriteByte(')')
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("dbus: cannot encode %s", t)
+}
+
+// nextType returns the length of the first complete type in sig.
+func nextType(sig string) (int, error) {
+	if sig == "" {
+		return 0, errors.New("dbus: empty signature")
+	}
+	switch sig[0] {
+	case 'a':
+		n, err := nextType(sig[1:])
+		return n + 1, err
+	case '(', '{':
+		closer := map[byte]byte{'(': ')', '{': '}'}[sig[0]]
+		i := 1
+		for i < len(sig) && sig[i] != closer {
+			n, err := nextType(sig[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+		if i == len(sig) {
+			return 0, fmt.Errorf("dbus: unterminated signature %q", sig)
+		}
+		return i + 1, nil
+	}
+	if !strings.ContainsRune("ybnqiuxtdsogvh", rune(sig[0])) {
+		return 0, fmt.Errorf("dbus: unknown type %q in signature", sig[0])
+	}
+	return 1, nil
+}
+
+// splitSignature splits sig into its complete types.
+func splitSignature(sig string) ([]string, error) {
+	var types []string
+	for sig != "" {
+		n, err := nextType(sig)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, sig[:n])
+		sig = sig[n:]
+	}
+	return types, nil
+}
+
+// alignment returns the boundary values of a type start on.
+func alignment(t byte) int {
+	switch t {
+	case 'y', 'g', 'v':
+		return 1
+	case 'n', 'q':
+		return 2
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+	return 4
+}
+
+// encoder writes values in little-endian D-Bus wire format. Offsets, and so
+// alignment, are relative to the start of buf.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.align(4)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, v)
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.buf = append(append(e.buf, s...), 0)
+}
+
+func (e *encoder) signature(s string) {
+	e.buf = append(append(append(e.buf, byte(len(s))), s...), 0)
+}
+
+// value encodes v as the single complete type sig.
+func (e *encoder) value(sig string, v reflect.Value) error {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return errors.New("dbus: cannot encode nil")
+		}
+		v = v.Elem()
+	}
+	e.align(alignment(sig[0]))
+	switch sig[0] {
+	case 'y':
+		e.buf = append(e.buf, byte(v.Uint()))
+	case 'b':
+		var b uint32
+		if v.Bool() {
+			b = 1
+		}
+		e.uint32(b)
+	case 'n':
+		e.buf = binary.LittleEndian.AppendUint16(e.buf, uint16(v.Int()))
+	case 'q':
+		e.buf = binary.LittleEndian.AppendUint16(e.buf, uint16(v.Uint()))
+	case 'i':
+		e.uint32(uint32(v.Int()))
+	case 'u':
+		e.uint32(uint32(v.Uint()))
+	case 'x':
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, uint64(v.Int()))
+	case 't':
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, v.Uint())
+	case 'd':
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v.Float()))
+	case 's', 'o':
+		e.string(v.String())
+	case 'g':
+		e.signature(v.String())
+	case 'v':
+		inner := v.Interface().(Variant).Value
+		s, err := SignatureOf(inner)
+		if err != nil {
+			return err
+		}
+		e.signature(string(s))
+		return e.value(string(s), reflect.ValueOf(inner))
+	case 'a':
+		e.uint32(0) // length, patched below
+		lenAt := len(e.buf) - 4
+		elem := sig[1:]
+		e.align(alignment(elem[0]))
+		start := len(e.buf)
+		if elem[0] == '{' {
+			types, err := splitSignature(elem[1 : len(elem)-1])
+			if err != nil {
+				return err
+			}
+			keys := v.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+			for _, k := range keys {
+				e.align(8)
+				if err := e.value(types[0], k); err != nil {
+					return err
+				}
+				if err := e.value(types[1], v.MapIndex(k)); err != nil {
+					return err
+				}
+			}
+		} else {
+			for i := 0; i < v.Len(); i++ {
+				if err := e.value(elem, v.Index(i)); err != nil {
+					return err
+				}
+			}
+		}
+		binary.LittleEndian.PutUint32(e.buf[lenAt:], uint32(len(e.buf)-start))
+	case '(':
+		types, err := splitSignature(sig[1 : len(sig)-1])
+		if err != nil {
+			return err
+		}
+		for i, t := range types {
+			var f reflect.Value
+			if v.Kind() == reflect.Struct {
+				f = v.Field(i)
+			} else {
+				f = v.Index(i)
+			}
+			if err := e.value(t, f); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("dbus: cannot encode type %q", sig)
+	}
+	return nil
+}
+
+// decoder reads values in D-Bus wire format. Offsets, and so alignment, are
+// relative to the start of buf.
+type decoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+var errShort = errors.New("dbus: message too short")
+
+func (d *decoder) align(n int) error {
+	d.pos = (d.pos + n - 1) / n * n
+	if d.pos > len(d.buf) {
+		return errShort
+	}
+	return nil
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, errShort
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	if err := d.align(4); err != nil {
+		return 0, err
+	}
+	b, err := d.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint32(b), nil
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.take(int(n) + 1)
+	if err != nil {
+		return "", err
+	}
+	return string(b[:n]), nil
+}
+
+func (d *decoder) signature() (string, error) {
+	b, err := d.take(1)
+	if err != nil {
+		return "", err
+	}
+	s, err := d.take(int(b[0]) + 1)
+	if err != nil {
+		return "", err
+	}
+	return string(s[:b[0]]), nil
+}
+
+// value decodes the single complete type sig. Arrays and structs decode to
+// []any, dictionaries to map[any]any and variants to Variant.
+func (d *decoder) value(sig string) (any, error) {
+	if err := d.align(alignment(sig[0])); err != nil {
+		return nil, err
+	}
+	fixed := func(n int) ([]byte, error) { return d.take(n) }
+	switch sig[0] {
+	case 'y':
+		b, err := fixed(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'b':
+		v, err := d.uint32()
+		return v != 0, err
+	case 'n', 'q':
+		b, err := fixed(2)
+		if err != nil {
+			return nil, err
+		}
+		if sig[0] == 'n' {
+			return int16(d.order.Uint16(b)), nil
+		}
+		return d.order.Uint16(b), nil
+	case 'i':
+		v, err := d.uint32()
+		return int32(v), err
+	case 'u', 'h':
+		return d.uint32()
+	case 'x', 't', 'd':
+		b, err := fixed(8)
+		if err != nil {
+			return nil, err
+		}
+		v := d.order.Uint64(b)
+		switch sig[0] {
+		case 'x':
+			return int64(v), nil
+		case 'd':
+			return math.Float64frombits(v), nil
+		}
+		return v, nil
+	case 's':
+		return d.string()
+	case 'o':
+		s, err := d.string()
+		return ObjectPath(s), err
+	case 'g':
+		s, err := d.signature()
+		return Signature(s), err
+	case 'v':
+		s, err := d.signature()
+		if err != nil {
+			return nil, err
+		}
+		if n, err := nextType(s); err != nil || n != len(s) {
+			return nil, fmt.Errorf("dbus: bad variant signature %q", s)
+		}
+		v, err := d.value(s)
+		return Variant{Value: v}, err
+	case 'a':
+		n, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		elem := sig[1:]
+		if err := d.align(alignment(elem[0])); err != nil {
+			return nil, err
+		}
+		end := d.pos + int(n)
+		if end > len(d.buf) {
+			return nil, errShort
+		}
+		if elem[0] == '{' {
+			types, err := splitSignature(elem[1 : len(elem)-1])
+			if err != nil {
+				return nil, err
+			}
+			m := make(map[any]any)
+			for d.pos < end {
+				if err := d.align(8); err != nil {
+					return nil, err
+				}
+				k, err := d.value(types[0])
+				if err != nil {
+					return nil, err
+				}
+				v, err := d.value(types[1])
+				if err != nil {
+					return nil, err
+				}
+				m[k] = v
+			}
+			return m, nil
+		}
+		var items []any
+		for d.pos < end {
+			v, err := d.value(elem)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case '(':
+		types, err := splitSignature(sig[1 : len(sig)-1])
+		if err != nil {
+			return nil, err
+		}
+		fields := make([]any, len(types))
+		for i, t := range types {
+			if fields[i], err = d.value(t); err != nil {
+				return nil, err
+			}
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("dbus: cannot decode type %q", sig)
+}
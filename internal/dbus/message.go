@@ -0,0 +1,203 @@
+package dbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// MessageType is the kind of a D-Bus message.
+type MessageType byte
+
+const (
+	TypeMethodCall   MessageType = 1
+	TypeMethodReturn MessageType = 2
+	TypeError        MessageType = 3
+	TypeSignal       MessageType = 4
+)
+
+// FlagNoReplyExpected marks a method call whose caller will not wait for a
+// reply.
+const FlagNoReplyExpected = 0x1
+
+// Header field codes.
+const (
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSender      = 7
+	fieldSignature   = 8
+)
+
+// maxMessageSize is the largest message the spec allows.
+const maxMessageSize = 128 << 20
+
+// Message is a D-Bus message.
+type Message struct {
+	Type        MessageType
+	Flags       byte
+	Serial      uint32
+	Path        ObjectPath
+	Interface   string
+	Member      string
+	ErrorName   string
+	ReplySerial uint32
+	Destination string
+	Sender      string
+	Signature   Signature
+	Body        []any
+}
+
+// Error is an error reply from a D-Bus peer.
+type Error struct {
+	Name    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// errorFrom converts an error reply into an *Error.
+func errorFrom(m *Message) *Error {
+	e := &Error{Name: m.ErrorName}
+	if len(m.Body) > 0 {
+		e.Message, _ = m.Body[0].(string)
+	}
+	return e
+}
+
+// encode returns the message in little-endian wire format. The body's
+// signature is worked out from Body.
+func (m *Message) encode() ([]byte, error) {
+	var body encoder
+	var sig strings.Builder
+	for _, arg := range m.Body {
+		s, err := SignatureOf(arg)
+		if err != nil {
+			return nil, err
+		}
+		sig.WriteString(string(s))
+		if err := body.value(string(s), reflect.ValueOf(arg)); err != nil {
+			return nil, err
+		}
+	}
+	m.Signature = Signature(sig.String())
+
+	type field struct {
+		code byte
+		sig  string
+		val  any
+	}
+	var fields []field
+	add := func(code byte, sig string, val any, set bool) {
+		if set {
+			fields = append(fields, field{code, sig, val})
+		}
+	}
+	add(fieldPath, "o", m.Path, m.Path != "")
+	add(fieldInterface, "s", m.Interface, m.Interface != "")
+	add(fieldMember, "s", m.Member, m.Member != "")
+	add(fieldErrorName, "s", m.ErrorName, m.ErrorName != "")
+	add(fieldReplySerial, "u", m.ReplySerial, m.ReplySerial != 0)
+	add(fieldDestination, "s", m.Destination, m.Destination != "")
+	add(fieldSender, "s", m.Sender, m.Sender != "")
+	add(fieldSignature, "g", m.Signature, m.Signature != "")
+
+	h := encoder{buf: []byte{'l', byte(m.Type), m.Flags, 1}}
+	h.uint32(uint32(len(body.buf)))
+	h.uint32(m.Serial)
+	h.uint32(0) // header fields length, patched below
+	start := len(h.buf)
+	for _, f := range fields {
+		h.align(8)
+		h.buf = append(h.buf, f.code)
+		h.signature(f.sig)
+		if err := h.value(f.sig, reflect.ValueOf(f.val)); err != nil {
+			return nil, err
+		}
+	}
+	binary.LittleEndian.PutUint32(h.buf[12:], uint32(len(h.buf)-start))
+	h.align(8)
+	return append(h.buf, body.buf...), nil
+}
+
+// readMessage reads one message from r.
+func readMessage(r io.Reader) (*Message, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("dbus: bad byte order %q", fixed[0])
+	}
+	bodyLen := int(order.Uint32(fixed[4:]))
+	fieldsLen := int(order.Uint32(fixed[12:]))
+	headerLen := (16 + fieldsLen + 7) / 8 * 8
+	if headerLen+bodyLen > maxMessageSize {
+		return nil, errors.New("dbus: message too large")
+	}
+	buf := make([]byte, headerLen+bodyLen)
+	copy(buf, fixed)
+	if _, err := io.ReadFull(r, buf[16:]); err != nil {
+		return nil, err
+	}
+
+	m := &Message{Type: MessageType(fixed[1]), Flags: fixed[2], Serial: order.Uint32(fixed[8:])}
+	h := decoder{buf: buf[:16+fieldsLen], pos: 12, order: order}
+	raw, err := h.value("a(yv)")
+	if err != nil {
+		return nil, fmt.Errorf("dbus: header fields: %w", err)
+	}
+	for _, f := range raw.([]any) {
+		pair := f.([]any)
+		v := pair[1].(Variant).Value
+		switch pair[0].(byte) {
+		case fieldPath:
+			m.Path, _ = v.(ObjectPath)
+		case fieldInterface:
+			m.Interface, _ = v.(string)
+		case fieldMember:
+			m.Member, _ = v.(string)
+		case fieldErrorName:
+			m.ErrorName, _ = v.(string)
+		case fieldReplySerial:
+			m.ReplySerial, _ = v.(uint32)
+		case fieldDestination:
+			m.Destination, _ = v.(string)
+		case fieldSender:
+			m.Sender, _ = v.(string)
+		case fieldSignature:
+			m.Signature, _ = v.(Signature)
+		}
+	}
+
+	types, err := splitSignature(string(m.Signature))
+	if err != nil {
+		return nil, err
+	}
+	b := decoder{buf: buf[headerLen:], order: order}
+	for _, t := range types {
+		v, err := b.value(t)
+		if err != nil {
+			return nil, fmt.Errorf("dbus: body: %w", err)
+		}
+		m.Body = append(m.Body, v)
+	}
+	return m, nil
+}
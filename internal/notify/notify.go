@@ -0,0 +1,143 @@
+// Package notify sends desktop notifications through the freedesktop
+// notification service (org.freedesktop.Notifications) on D-Bus.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/dbus"
+)
+
+const (
+	service = "org.freedesktop.Notifications"
+	path    = dbus.ObjectPath("/org/freedesktop/Notifications")
+)
+
+// callTimeout bounds how long a notification server may take to answer.
+const callTimeout = 5 * time.Second
+
+// Urgency is how important a notification is. Servers may show critical
+// notifications until they are dismissed.
+type Urgency byte
+
+const (
+	Low      Urgency = 0
+	Normal   Urgency = 1
+	Critical Urgency = 2
+)
+
+// Action is a button on a notification. Do runs when the user presses it.
+type Action struct {
+	Key   string
+	Label string
+	Do    func()
+}
+
+// Notifier sends notifications and runs their actions.
+type Notifier struct {
+	conn    *dbus.Conn
+	appName string
+
+	mu      sync.Mutex
+	actions map[uint32][]Action // by notification ID, until it is closed
+}
+
+// New creates a Notifier that sends notifications over conn on behalf of
+// appName, and listens for their actions.
+func New(conn *dbus.Conn, appName string) (*Notifier, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	rule := fmt.Sprintf("type='signal',sender='%s',interface='%s',path='%s'", service, service, path)
+	if err := conn.AddMatch(ctx, rule); err != nil {
+		return nil, fmt.Errorf("listen for notification actions: %w", err)
+	}
+	n := &Notifier{conn: conn, appName: appName, actions: make(map[uint32][]Action)}
+	go n.dispatch()
+	return n, nil
+}
+
+// Notify shows a notification and returns its ID. If replaces is not zero
+// the notification with that ID is updated instead.
+func (n *Notifier) Notify(replaces uint32, title, body string, urgency Urgency, actions ...Action) (uint32, error) {
+	keys := []string{}
+	for _, a := range actions {
+		keys = append(keys, a.Key, a.Label)
+	}
+	hints := map[string]dbus.Variant{"urgency": {Value: byte(urgency)}}
+
+	// Holding the lock until the actions are recorded means a quick click
+	// cannot be dispatched before we know the notification's ID.
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	reply, err := n.conn.Call(ctx, service, path, service, "Notify",
+		n.appName, replaces, "", title, body, keys, hints, int32(-1))
+	if err != nil {
+		return 0, fmt.Errorf("send notification: %w", err)
+	}
+	id, ok := firstUint32(reply)
+	if !ok {
+		return 0, fmt.Errorf("send notification: unexpected reply %v", reply)
+	}
+	if len(actions) > 0 {
+		n.actions[id] = actions
+	} else {
+		delete(n.actions, id)
+	}
+	return id, nil
+}
+
+// Close removes a notification from the screen.
+func (n *Notifier) Close(id uint32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	if _, err := n.conn.Call(ctx, service, path, service, "CloseNotification", id); err != nil {
+		return fmt.Errorf("close notification: %w", err)
+	}
+	return nil
+}
+
+// dispatch runs actions as the server reports them, until the connection
+// ends.
+func (n *Notifier) dispatch() {
+	for m := range n.conn.Incoming() {
+		if m.Type != dbus.TypeSignal || m.Interface != service {
+			continue
+		}
+		id, ok := firstUint32(m.Body)
+		if !ok {
+			continue
+		}
+		switch m.Member {
+		case "ActionInvoked":
+			if len(m.Body) < 2 {
+				continue
+			}
+			key, _ := m.Body[1].(string)
+			n.mu.Lock()
+			actions := n.actions[id]
+			n.mu.Unlock()
+			for _, a := range actions {
+				if a.Key == key && a.Do != nil {
+					a.Do()
+				}
+			}
+		case "NotificationClosed":
+			n.mu.Lock()
+			delete(n.actions, id)
+			n.mu.Unlock()
+		}
+	}
+}
+
+func firstUint32(body []any) (uint32, bool) {
+	if len(body) == 0 {
+		return 0, false
+	}
+	v, ok := body[0].(uint32)
+	return v, ok
+}
@@ -0,0 +1,134 @@
+package notify
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/dbus"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/dbus/dbustest"
+)
+
+// fakeServer answers Notify calls like a notification daemon, handing the
+// calls to the test.
+func fakeServer(t *testing.T, addr string) (*dbus.Conn, <-chan *dbus.Message) {
+	t.Helper()
+	conn, err := dbus.Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := conn.RequestName(context.Background(), service); err != nil {
+		t.Fatalf("RequestName failed: %v", err)
+	}
+	calls := make(chan *dbus.Message, 10)
+	go func() {
+		next := uint32(0)
+		for m := range conn.Incoming() {
+			if m.Type != dbus.TypeMethodCall {
+				continue
+			}
+			switch m.Member {
+			case "Notify":
+				id := m.Body[1].(uint32)
+				if id == 0 {
+					next++
+					id = next
+				}
+				conn.Reply(m, id)
+			default:
+				conn.Reply(m)
+			}
+			calls <- m
+		}
+	}()
+	return conn, calls
+}
+
+func TestNotifier(t *testing.T) {
+	addr := dbustest.StartBus(t)
+	server, calls := fakeServer(t, addr)
+
+	client, err := dbus.Dial(addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+	n, err := New(client, "clock")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	stopped := make(chan bool, 1)
+	id, err := n.Notify(0, "Azan", "Time for Maghrib prayer", Critical,
+		Action{Key: "stop", Label: "Stop azan", Do: func() { stopped <- true }})
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if id != 1 {
+		t.Errorf("expected ID 1, got %d", id)
+	}
+
+	call := <-calls
+	want := []any{
+		"clock", uint32(0), "", "Azan", "Time for Maghrib prayer",
+		[]any{"stop", "Stop azan"},
+		map[any]any{"urgency": dbus.Variant{Value: byte(Critical)}},
+		int32(-1),
+	}
+	if !reflect.DeepEqual(call.Body, want) {
+		t.Errorf("Notify sent %#v, want %#v", call.Body, want)
+	}
+
+	// A different notification's action must not run ours.
+	server.Emit(path, service, "ActionInvoked", uint32(99), "stop")
+	server.Emit(path, service, "ActionInvoked", id, "stop")
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("action not run")
+	}
+	select {
+	case <-stopped:
+		t.Error("action run twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Once closed, the notification's actions are forgotten.
+	server.Emit(path, service, "NotificationClosed", id, uint32(2))
+	if err := n.Close(id); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if m := <-calls; m.Member != "CloseNotification" || m.Body[0] != id {
+		t.Errorf("unexpected call %s %v", m.Member, m.Body)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		n.mu.Lock()
+		left := len(n.actions)
+		n.mu.Unlock()
+		if left == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected actions to be forgotten, %d left", left)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNotifier_NoServer(t *testing.T) {
+	client, err := dbus.Dial(dbustest.StartBus(t))
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+	n, err := New(client, "clock")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := n.Notify(0, "Azan", "Time for Isha prayer", Normal); err == nil {
+		t.Error("expected an error without a notification server")
+	}
+}
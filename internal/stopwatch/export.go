@@ -0,0 +1,118 @@
+package stopwatch
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Export formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// FormatDuration formats d as H:MM:SS.mmm, the form spreadsheets read as a
+// time.
+func FormatDuration(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// WriteCSV writes laps as CSV with a header row.
+func WriteCSV(w io.Writer, laps []Lap) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"lap", "lap_time", "split"})
+	for _, l := range laps {
+		cw.Write([]string{strconv.Itoa(l.Number), FormatDuration(l.Time), FormatDuration(l.Split)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonLap is how a lap is written as JSON, with times both readable and in
+// milliseconds.
+type jsonLap struct {
+	Lap     int    `json:"lap"`
+	LapTime string `json:"lap_time"`
+	Split   string `json:"split"`
+	LapMS   int64  `json:"lap_ms"`
+	SplitMS int64  `json:"split_ms"`
+}
+
+// WriteJSON writes laps as a JSON array.
+func WriteJSON(w io.Writer, laps []Lap) error {
+	out := make([]jsonLap, len(laps))
+	for i, l := range laps {
+		out[i] = jsonLap{
+			Lap:     l.Number,
+			LapTime: FormatDuration(l.Time),
+			Split:   FormatDuration(l.Split),
+			LapMS:   l.Time.Milliseconds(),
+			SplitMS: l.Split.Milliseconds(),
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// Export writes the laps of the stopwatch called name in format ("csv" or
+// "json") to a new file in dir named after it and now, and returns its
+// path. An existing file is never overwritten: a number is added to the
+// name instead.
+func Export(dir, format, name string, laps []Lap, now time.Time) (string, error) {
+	write := WriteCSV
+	switch format {
+	case FormatCSV:
+	case FormatJSON:
+		write = WriteJSON
+	default:
+		return "", fmt.Errorf("unknown export format %q", format)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("export laps: %w", err)
+	}
+	base := "laps-"
+	if name = fileSafe(name); name != "" {
+		base += name + "-"
+	}
+	base += now.Format("2006-01-02T15-04-05")
+	path := filepath.Join(dir, base+"."+format)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	for n := 2; errors.Is(err, fs.ErrExist); n++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.%s", base, n, format))
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	}
+	if err != nil {
+		return "", fmt.Errorf("export laps: %w", err)
+	}
+	if err := write(f, laps); err != nil {
+		f.Close()
+		return "", fmt.Errorf("export laps: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("export laps: %w", err)
+	}
+	return path, nil
+}
+
+// fileSafe turns a stopwatch's name into something fit for a file name,
+// replacing anything but letters, digits, '-' and '_' with '_'.
+func fileSafe(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+}
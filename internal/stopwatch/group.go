@@ -0,0 +1,228 @@
+package stopwatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/atomicfile"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+)
+
+// DefaultName is the name of the stopwatch a new group starts with.
+const DefaultName = "Stopwatch"
+
+// Named is a stopwatch with a name, such as a ticket or a task.
+type Named struct {
+	Name string
+	*Stopwatch
+}
+
+// Group is a set of named stopwatches, saved together. Each time one of
+// them stops, the time it ran is logged to a timesheet. It is safe for
+// concurrent use.
+type Group struct {
+	// Exclusive makes starting a stopwatch stop the others.
+	Exclusive bool
+
+	clock   chrono.Clock
+	mu      sync.Mutex
+	watches []Named
+	path    string // where the group is saved
+	log     string // where intervals are logged
+}
+
+// groupFile is how a group is saved.
+type groupFile struct {
+	Watches []namedState `json:"watches"`
+}
+
+type namedState struct {
+	Name string `json:"name"`
+	State
+}
+
+// LoadGroup restores the group saved at path, logging intervals to log. A
+// missing file yields a group with a single stopwatch, and a file saved by
+// a single Stopwatch becomes a group holding it.
+func LoadGroup(path, log string) (*Group, error) {
+	return LoadGroupWithClock(path, log, chrono.System)
+}
+
+// LoadGroupWithClock is LoadGroup for stopwatches that read the time
+// from c.
+func LoadGroupWithClock(path, log string, c chrono.Clock) (*Group, error) {
+	g := &Group{clock: c, path: path, log: log}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		g.watches = []Named{{Name: DefaultName, Stopwatch: NewWithClock(c)}}
+		return g, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read stopwatches: %w", err)
+	}
+	var file struct {
+		groupFile
+		State
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse stopwatches %s: %w", path, err)
+	}
+	if file.Watches == nil {
+		g.watches = []Named{{Name: DefaultName, Stopwatch: RestoreWithClock(file.State, c)}}
+		return g, nil
+	}
+	for _, w := range file.Watches {
+		g.watches = append(g.watches, Named{Name: w.Name, Stopwatch: RestoreWithClock(w.State, c)})
+	}
+	return g, nil
+}
+
+// Save writes the group to its file.
+func (g *Group) Save() error {
+	g.mu.Lock()
+	file := groupFile{Watches: []namedState{}}
+	for _, w := range g.watches {
+		file.Watches = append(file.Watches, namedState{Name: w.Name, State: w.State()})
+	}
+	g.mu.Unlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode stopwatches: %w", err)
+	}
+	if err := atomicfile.Write(g.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("save stopwatches: %w", err)
+	}
+	return nil
+}
+
+// Watches returns the stopwatches in the order they were added.
+func (g *Group) Watches() []Named {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]Named(nil), g.watches...)
+}
+
+// Get returns the stopwatch called name.
+func (g *Group) Get(name string) (*Stopwatch, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	i := g.find(name)
+	if i < 0 {
+		return nil, false
+	}
+	return g.watches[i].Stopwatch, true
+}
+
+func (g *Group) find(name string) int {
+	for i, w := range g.watches {
+		if w.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add adds a stopped stopwatch called name.
+func (g *Group) Add(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("stopwatch needs a name")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.find(name) >= 0 {
+		return fmt.Errorf("stopwatch %q already exists", name)
+	}
+	g.watches = append(g.watches, Named{Name: name, Stopwatch: NewWithClock(g.clock)})
+	return nil
+}
+
+// Remove stops the stopwatch called name, logging the time it ran, and
+// removes it.
+func (g *Group) Remove(name string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	i := g.find(name)
+	if i < 0 {
+		return nil
+	}
+	ended := g.stop(g.watches[i], g.clock.Now())
+	g.watches = append(g.watches[:i], g.watches[i+1:]...)
+	return g.record(ended)
+}
+
+// Toggle starts or stops the stopwatch called name, logging the time it
+// ran when it stops. In an exclusive group, starting it stops the others.
+func (g *Group) Toggle(name string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	i := g.find(name)
+	if i < 0 {
+		return nil
+	}
+	now := g.clock.Now()
+	w := g.watches[i]
+	if w.IsRunning() {
+		return g.record(g.stop(w, now))
+	}
+	var ended []Interval
+	if g.Exclusive {
+		for _, other := range g.watches {
+			ended = append(ended, g.stop(other, now)...)
+		}
+	}
+	w.start(now)
+	return g.record(ended)
+}
+
+// Reset stops the stopwatch called name, logging the time it ran, and
+// resets it.
+func (g *Group) Reset(name string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	i := g.find(name)
+	if i < 0 {
+		return nil
+	}
+	ended := g.stop(g.watches[i], g.clock.Now())
+	g.watches[i].Reset()
+	return g.record(ended)
+}
+
+// Running returns what the running stopwatches have run for until now,
+// which is not logged until they stop.
+func (g *Group) Running(now time.Time) []Interval {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var running []Interval
+	for _, w := range g.watches {
+		if st := w.State(); st.Running {
+			running = append(running, Interval{Name: w.Name, Start: st.Started, End: now})
+		}
+	}
+	return running
+}
+
+// stop stops w if it is running, returning the interval it ran for.
+func (g *Group) stop(w Named, now time.Time) []Interval {
+	started, ok := w.stop(now)
+	if !ok {
+		return nil
+	}
+	return []Interval{{Name: w.Name, Start: started, End: now}}
+}
+
+// record logs ended intervals to the timesheet.
+func (g *Group) record(ended []Interval) error {
+	if len(ended) == 0 {
+		return nil
+	}
+	return AppendLog(g.log, ended...)
+}
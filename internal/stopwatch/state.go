@@ -0,0 +1,81 @@
+package stopwatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/atomicfile"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+)
+
+// State is everything needed to bring a stopwatch back after a restart.
+// A running stopwatch is stored with the wall time it was started at, so
+// that it keeps counting while nothing is running it.
+type State struct {
+	Running bool          `json:"running"`
+	Started time.Time     `json:"started,omitempty"` // while running
+	Elapsed time.Duration `json:"elapsed"`           // before Started
+	Laps    []Lap         `json:"laps,omitempty"`
+}
+
+// State returns the stopwatch's state.
+func (s *Stopwatch) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := State{Running: s.running, Elapsed: s.elapsed, Laps: append([]Lap(nil), s.laps...)}
+	if s.running {
+		// Drop the monotonic reading, which means nothing after a restart.
+		st.Started = s.startTime.Round(0)
+	}
+	return st
+}
+
+// Restore creates a stopwatch from a saved state.
+func Restore(st State) *Stopwatch {
+	return RestoreWithClock(st, chrono.System)
+}
+
+// RestoreWithClock creates a stopwatch from a saved state that reads the
+// time from c.
+func RestoreWithClock(st State, c chrono.Clock) *Stopwatch {
+	return &Stopwatch{
+		clock:     c,
+		startTime: st.Started,
+		elapsed:   st.Elapsed,
+		running:   st.Running,
+		laps:      append([]Lap(nil), st.Laps...),
+	}
+}
+
+// Load restores the stopwatch saved at path. A missing file yields a new
+// stopwatch.
+func Load(path string) (*Stopwatch, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read stopwatch: %w", err)
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("parse stopwatch %s: %w", path, err)
+	}
+	return Restore(st), nil
+}
+
+// Save writes the stopwatch's state to path.
+func (s *Stopwatch) Save(path string) error {
+	data, err := json.MarshalIndent(s.State(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode stopwatch: %w", err)
+	}
+	if err := atomicfile.Write(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("save stopwatch: %w", err)
+	}
+	return nil
+}
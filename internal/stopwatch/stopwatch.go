@@ -1,45 +1,150 @@
 package stopwatch
 
-import "time"
+import (
+	"sync"
+	"time"
 
-// Stopwatch tracks elapsed time with start/stop/reset functionality.
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+)
+
+// Stopwatch tracks elapsed time with start/stop/reset functionality. It is
+// safe for concurrent use.
 type Stopwatch struct {
+	clock     chrono.Clock
+	mu        sync.Mutex
 	startTime time.Time
 	elapsed   time.Duration
 	running   bool
+	laps      []Lap
+}
+
+// Lap is one recorded lap.
+type Lap struct {
+	Number int           `json:"number"`
+	Time   time.Duration `json:"time"`  // length of the lap
+	Split  time.Duration `json:"split"` // elapsed time at the end of the lap
 }
 
 // New creates a new Stopwatch.
 func New() *Stopwatch {
-	return &Stopwatch{}
+	return NewWithClock(chrono.System)
+}
+
+// NewWithClock creates a new Stopwatch that reads the time from c.
+func NewWithClock(c chrono.Clock) *Stopwatch {
+	return &Stopwatch{clock: c}
 }
 
 // Toggle starts or stops the stopwatch.
 func (s *Stopwatch) Toggle() {
-	if s.running {
-		s.elapsed += time.Since(s.startTime)
-		s.running = false
-	} else {
-		s.startTime = time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	if _, ok := s.stopLocked(now); !ok {
+		s.startLocked(now)
+	}
+}
+
+// start starts the stopwatch at now unless it is running.
+func (s *Stopwatch) start(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startLocked(now)
+}
+
+func (s *Stopwatch) startLocked(now time.Time) {
+	if !s.running {
+		s.startTime = now
 		s.running = true
 	}
 }
 
-// Reset resets the stopwatch to zero.
+// stop stops the stopwatch at now if it is running, returning when it
+// was started.
+func (s *Stopwatch) stop(now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopLocked(now)
+}
+
+func (s *Stopwatch) stopLocked(now time.Time) (time.Time, bool) {
+	if !s.running {
+		return time.Time{}, false
+	}
+	s.elapsed += now.Sub(s.startTime)
+	s.running = false
+	return s.startTime, true
+}
+
+// Reset resets the stopwatch to zero and clears its laps.
 func (s *Stopwatch) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.elapsed = 0
 	s.running = false
+	s.laps = nil
 }
 
 // Elapsed returns the current elapsed duration.
 func (s *Stopwatch) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.elapsedLocked()
+}
+
+func (s *Stopwatch) elapsedLocked() time.Duration {
 	if s.running {
-		return s.elapsed + time.Since(s.startTime)
+		return s.elapsed + s.clock.Since(s.startTime)
 	}
 	return s.elapsed
 }
 
 // IsRunning returns whether the stopwatch is currently running.
 func (s *Stopwatch) IsRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.running
 }
+
+// Lap ends the current lap at the elapsed time and starts the next. It
+// returns false, recording nothing, if no time has passed since the last
+// lap.
+func (s *Stopwatch) Lap() (Lap, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	split := s.elapsedLocked()
+	var last time.Duration
+	if n := len(s.laps); n > 0 {
+		last = s.laps[n-1].Split
+	}
+	if split <= last {
+		return Lap{}, false
+	}
+	lap := Lap{Number: len(s.laps) + 1, Time: split - last, Split: split}
+	s.laps = append(s.laps, lap)
+	return lap, true
+}
+
+// Laps returns the recorded laps, first to last.
+func (s *Stopwatch) Laps() []Lap {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Lap(nil), s.laps...)
+}
+
+// Extremes returns the indexes in laps of the fastest and slowest lap. It
+// returns -1 for both when there are fewer than two laps to compare.
+func Extremes(laps []Lap) (fastest, slowest int) {
+	if len(laps) < 2 {
+		return -1, -1
+	}
+	for i, l := range laps {
+		if l.Time < laps[fastest].Time {
+			fastest = i
+		}
+		if l.Time > laps[slowest].Time {
+			slowest = i
+		}
+	}
+	return fastest, slowest
+}
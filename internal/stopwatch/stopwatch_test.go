@@ -0,0 +1,387 @@
+package stopwatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+)
+
+func TestStopwatch_Laps(t *testing.T) {
+	clk := chrono.NewFake(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+	s := NewWithClock(clk)
+	if _, ok := s.Lap(); ok {
+		t.Error("expected no lap before the stopwatch has run")
+	}
+	s.Toggle()
+	clk.Advance(20 * time.Second)
+	first, ok := s.Lap()
+	if !ok || first != (Lap{1, 20 * time.Second, 20 * time.Second}) {
+		t.Errorf("unexpected first lap %+v", first)
+	}
+	clk.Advance(10 * time.Second)
+	second, _ := s.Lap()
+	if second != (Lap{2, 10 * time.Second, 30 * time.Second}) {
+		t.Errorf("expected the split to add up, got %+v after %+v", second, first)
+	}
+
+	s.Toggle()
+	clk.Advance(time.Hour)
+	if s.Elapsed() != 30*time.Second {
+		t.Errorf("expected a stopped stopwatch to hold 30s, got %v", s.Elapsed())
+	}
+	if _, ok := s.Lap(); ok {
+		t.Error("expected no empty lap while stopped")
+	}
+	s.Toggle()
+	clk.Advance(5 * time.Millisecond)
+	if s.Elapsed() != 30*time.Second+5*time.Millisecond {
+		t.Errorf("expected to resume from 30s, got %v", s.Elapsed())
+	}
+	s.Lap()
+	if n := len(s.Laps()); n != 3 {
+		t.Errorf("expected 3 laps, got %d", n)
+	}
+	s.Reset()
+	if len(s.Laps()) != 0 || s.Elapsed() != 0 {
+		t.Error("expected Reset to clear the laps")
+	}
+}
+
+var testLaps = []Lap{
+	{1, 62345 * time.Millisecond, 62345 * time.Millisecond},
+	{2, 58 * time.Second, 120345 * time.Millisecond},
+	{3, 3601 * time.Second, 3721345 * time.Millisecond},
+}
+
+func TestExtremes(t *testing.T) {
+	if f, s := Extremes(testLaps); f != 1 || s != 2 {
+		t.Errorf("Extremes = %d, %d; want 1, 2", f, s)
+	}
+	if f, s := Extremes(testLaps[:1]); f != -1 || s != -1 {
+		t.Errorf("expected nothing to compare a single lap with, got %d, %d", f, s)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCSV(&b, testLaps); err != nil {
+		t.Fatal(err)
+	}
+	want := "lap,lap_time,split\n1,0:01:02.345,0:01:02.345\n2,0:00:58.000,0:02:00.345\n3,1:00:01.000,1:02:01.345\n"
+	if b.String() != want {
+		t.Errorf("WriteCSV wrote\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "laps")
+	now := time.Date(2024, 6, 3, 9, 30, 0, 0, time.Local)
+	path, err := Export(dir, FormatJSON, "", testLaps, now)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if filepath.Base(path) != "laps-2024-06-03T09-30-00.json" {
+		t.Errorf("unexpected file name %s", path)
+	}
+	data, _ := os.ReadFile(path)
+	var laps []jsonLap
+	if err := json.Unmarshal(data, &laps); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(laps) != 3 || laps[2].SplitMS != 3721345 || laps[0].LapTime != "0:01:02.345" {
+		t.Errorf("unexpected export %+v", laps)
+	}
+
+	if _, err := Export(dir, "xml", "", testLaps, now); err == nil || !strings.Contains(err.Error(), "xml") {
+		t.Errorf("expected an unknown format to be refused, got %v", err)
+	}
+}
+
+func TestExport_Names(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 6, 3, 9, 30, 0, 0, time.Local)
+	var names []string
+	for _, name := range []string{"TICKET-1", "TICKET-1", "Code review", "a/b"} {
+		path, err := Export(dir, FormatCSV, name, testLaps, now)
+		if err != nil {
+			t.Fatalf("Export %q failed: %v", name, err)
+		}
+		names = append(names, filepath.Base(path))
+	}
+	want := []string{
+		"laps-TICKET-1-2024-06-03T09-30-00.csv",
+		"laps-TICKET-1-2024-06-03T09-30-00-2.csv",
+		"laps-Code_review-2024-06-03T09-30-00.csv",
+		"laps-a_b-2024-06-03T09-30-00.csv",
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("export %d named %s, want %s", i, names[i], want[i])
+		}
+	}
+}
+
+func TestStopwatch_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stopwatch.json")
+	s, err := Load(path)
+	if err != nil || s.IsRunning() || s.Elapsed() != 0 {
+		t.Fatalf("Load of a missing file = %v, %v", s, err)
+	}
+
+	s.Toggle()
+	time.Sleep(20 * time.Millisecond)
+	s.Lap()
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved := s.Elapsed()
+
+	// The clock is closed for a while, then started again.
+	time.Sleep(30 * time.Millisecond)
+	restored, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !restored.IsRunning() {
+		t.Fatal("expected a running stopwatch to still be running")
+	}
+	if got := restored.Elapsed(); got < saved+30*time.Millisecond {
+		t.Errorf("expected the time while closed to count, got %v after %v", got, saved)
+	}
+	if laps := restored.Laps(); len(laps) != 1 || laps[0] != s.Laps()[0] {
+		t.Errorf("expected the laps back, got %+v", laps)
+	}
+
+	restored.Toggle()
+	stopped := restored.Elapsed()
+	restored.Save(path)
+	time.Sleep(10 * time.Millisecond)
+	again, _ := Load(path)
+	if again.IsRunning() || again.Elapsed() != stopped {
+		t.Errorf("expected a stopped stopwatch to hold %v, got %v", stopped, again.Elapsed())
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stopwatch.json")
+	os.WriteFile(path, []byte("{"), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for a corrupt file")
+	}
+}
+
+func TestStopwatch_Concurrent(t *testing.T) {
+	s := New()
+	path := filepath.Join(t.TempDir(), "stopwatch.json")
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			s.Save(path)
+		}
+		done <- true
+	}()
+	for i := 0; i < 100; i++ {
+		s.Toggle()
+		s.Lap()
+		s.Elapsed()
+	}
+	<-done
+}
+
+func TestGroup_Exclusive(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "timesheet.csv")
+	g, err := LoadGroup(filepath.Join(dir, "stopwatch.json"), log)
+	if err != nil {
+		t.Fatalf("LoadGroup failed: %v", err)
+	}
+	if w := g.Watches(); len(w) != 1 || w[0].Name != DefaultName {
+		t.Fatalf("expected a new group to hold %q, got %+v", DefaultName, w)
+	}
+	if err := g.Add("TICKET-1"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := g.Add("TICKET-1"); err == nil {
+		t.Error("expected an error adding a name twice")
+	}
+	if err := g.Add("  "); err == nil {
+		t.Error("expected an error adding an empty name")
+	}
+
+	g.Exclusive = true
+	g.Toggle(DefaultName)
+	g.Toggle("TICKET-1")
+	first, _ := g.Get(DefaultName)
+	second, _ := g.Get("TICKET-1")
+	if first.IsRunning() || !second.IsRunning() {
+		t.Error("expected starting one stopwatch to stop the other")
+	}
+	g.Toggle("TICKET-1")
+
+	intervals, err := ReadLog(log)
+	if err != nil {
+		t.Fatalf("ReadLog failed: %v", err)
+	}
+	if len(intervals) != 2 || intervals[0].Name != DefaultName || intervals[1].Name != "TICKET-1" {
+		t.Errorf("expected both intervals logged in order, got %+v", intervals)
+	}
+}
+
+func TestGroup_Intervals(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "timesheet.csv")
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	clk := chrono.NewFake(start)
+	g, _ := LoadGroupWithClock(filepath.Join(dir, "stopwatch.json"), log, clk)
+	g.Exclusive = true
+	g.Add("Review")
+	g.Toggle(DefaultName)
+	clk.Advance(90 * time.Minute)
+	g.Toggle("Review")
+	clk.Advance(30 * time.Minute)
+	if running := g.Running(clk.Now()); len(running) != 1 || running[0].Start != start.Add(90*time.Minute) {
+		t.Errorf("unexpected running intervals %+v", running)
+	}
+	g.Reset("Review")
+
+	intervals, _ := ReadLog(log)
+	want := []Interval{
+		{DefaultName, start, start.Add(90 * time.Minute)},
+		{"Review", start.Add(90 * time.Minute), start.Add(2 * time.Hour)},
+	}
+	if len(intervals) != len(want) {
+		t.Fatalf("logged %+v, want %+v", intervals, want)
+	}
+	for i := range want {
+		if intervals[i].Name != want[i].Name || !intervals[i].Start.Equal(want[i].Start) || !intervals[i].End.Equal(want[i].End) {
+			t.Errorf("interval %d = %+v, want %+v", i, intervals[i], want[i])
+		}
+	}
+}
+
+func TestGroup_Shared(t *testing.T) {
+	dir := t.TempDir()
+	g, _ := LoadGroup(filepath.Join(dir, "stopwatch.json"), filepath.Join(dir, "timesheet.csv"))
+	g.Add("b")
+	g.Toggle(DefaultName)
+	g.Toggle("b")
+	first, _ := g.Get(DefaultName)
+	second, _ := g.Get("b")
+	if !first.IsRunning() || !second.IsRunning() {
+		t.Error("expected stopwatches to run together unless exclusive")
+	}
+	if running := g.Running(time.Now()); len(running) != 2 {
+		t.Errorf("expected 2 running intervals, got %+v", running)
+	}
+}
+
+func TestGroup_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	path, log := filepath.Join(dir, "stopwatch.json"), filepath.Join(dir, "timesheet.csv")
+	g, _ := LoadGroup(path, log)
+	g.Add("Review")
+	g.Toggle("Review")
+	if err := g.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	restored, err := LoadGroup(path, log)
+	if err != nil {
+		t.Fatalf("LoadGroup failed: %v", err)
+	}
+	w := restored.Watches()
+	if len(w) != 2 || w[1].Name != "Review" || !w[1].IsRunning() {
+		t.Errorf("expected the running stopwatch back, got %+v", w)
+	}
+	restored.Remove("Review")
+	if _, ok := restored.Get("Review"); ok {
+		t.Error("expected Remove to remove the stopwatch")
+	}
+	if intervals, _ := ReadLog(log); len(intervals) != 1 {
+		t.Errorf("expected removing a running stopwatch to log it, got %+v", intervals)
+	}
+}
+
+func TestLoadGroup_Single(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stopwatch.json")
+	s := New()
+	s.Toggle()
+	s.Save(path)
+	g, err := LoadGroup(path, filepath.Join(t.TempDir(), "timesheet.csv"))
+	if err != nil {
+		t.Fatalf("LoadGroup failed: %v", err)
+	}
+	if w := g.Watches(); len(w) != 1 || w[0].Name != DefaultName || !w[0].IsRunning() {
+		t.Errorf("expected a single stopwatch's file to load as one stopwatch, got %+v", w)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	intervals := []Interval{
+		{"B", at("2026-10-12 09:00"), at("2026-10-12 10:30")},
+		{"A", at("2026-10-12 11:00"), at("2026-10-12 11:45")},
+		{"A", at("2026-10-12 23:00"), at("2026-10-13 01:00")}, // crosses midnight
+		{"A", at("2026-10-19 08:00"), at("2026-10-19 09:00")}, // next week
+	}
+	days := Summarize(intervals, Daily, time.UTC)
+	want := []Row{
+		{at("2026-10-12 00:00"), "A", 105 * time.Minute},
+		{at("2026-10-12 00:00"), "B", 90 * time.Minute},
+		{at("2026-10-13 00:00"), "A", time.Hour},
+		{at("2026-10-19 00:00"), "A", time.Hour},
+	}
+	if len(days) != len(want) {
+		t.Fatalf("Summarize by day = %+v, want %+v", days, want)
+	}
+	for i := range want {
+		if !days[i].Start.Equal(want[i].Start) || days[i].Name != want[i].Name || days[i].Total != want[i].Total {
+			t.Errorf("row %d = %+v, want %+v", i, days[i], want[i])
+		}
+	}
+
+	weeks := Summarize(intervals, Weekly, time.UTC)
+	if len(weeks) != 3 || weeks[0].Total != 165*time.Minute || !weeks[2].Start.Equal(at("2026-10-19 00:00")) {
+		t.Errorf("Summarize by week = %+v", weeks)
+	}
+
+	var b bytes.Buffer
+	WriteReportCSV(&b, weeks, Weekly)
+	if got := b.String(); !strings.HasPrefix(got, "week,name,hours\n2026-W42,A,2.75\n2026-W42,B,1.50\n") {
+		t.Errorf("unexpected CSV:\n%s", got)
+	}
+	b.Reset()
+	WriteReport(&b, days, Daily)
+	if got := b.String(); !strings.Contains(got, "2026-10-12 Mon  A      1:45") || !strings.Contains(got, "Total  3:15") {
+		t.Errorf("unexpected table:\n%s", got)
+	}
+}
+
+func TestReadLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timesheet.csv")
+	if intervals, err := ReadLog(path); err != nil || intervals != nil {
+		t.Errorf("ReadLog of a missing file = %v, %v", intervals, err)
+	}
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	AppendLog(path, Interval{"a, b", start, start.Add(time.Hour)})
+	AppendLog(path, Interval{"c", start, start.Add(time.Minute)})
+	intervals, err := ReadLog(path)
+	if err != nil || len(intervals) != 2 || intervals[0].Name != "a, b" || !intervals[1].End.Equal(start.Add(time.Minute)) {
+		t.Errorf("ReadLog = %+v, %v", intervals, err)
+	}
+	os.WriteFile(path, []byte("x,not a time,\n"), 0644)
+	if _, err := ReadLog(path); err == nil {
+		t.Error("expected an error for a corrupt timesheet")
+	}
+}
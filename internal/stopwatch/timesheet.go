@@ -0,0 +1,211 @@
+package stopwatch
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Interval is a stretch of time a named stopwatch ran for.
+type Interval struct {
+	Name       string
+	Start, End time.Time
+}
+
+// AppendLog adds intervals to the timesheet at path, a CSV file of names
+// with start and end times, creating it if needed.
+func AppendLog(path string, intervals ...Interval) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("log stopwatch: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("log stopwatch: %w", err)
+	}
+	w := csv.NewWriter(f)
+	for _, iv := range intervals {
+		w.Write([]string{iv.Name, iv.Start.Format(time.RFC3339), iv.End.Format(time.RFC3339)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return fmt.Errorf("log stopwatch: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("log stopwatch: %w", err)
+	}
+	return nil
+}
+
+// ReadLog reads the timesheet at path. A missing file is an empty
+// timesheet.
+func ReadLog(path string) ([]Interval, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read timesheet: %w", err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 3
+	var intervals []Interval
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return intervals, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read timesheet %s: %w", path, err)
+		}
+		start, err := time.Parse(time.RFC3339, rec[1])
+		if err != nil {
+			return nil, fmt.Errorf("read timesheet %s: %w", path, err)
+		}
+		end, err := time.Parse(time.RFC3339, rec[2])
+		if err != nil {
+			return nil, fmt.Errorf("read timesheet %s: %w", path, err)
+		}
+		intervals = append(intervals, Interval{Name: rec[0], Start: start, End: end})
+	}
+}
+
+// Period is how a report groups time.
+type Period int
+
+const (
+	Daily Period = iota
+	Weekly
+)
+
+// ParsePeriod reads "day" or "week".
+func ParsePeriod(s string) (Period, error) {
+	switch s {
+	case "day", "daily":
+		return Daily, nil
+	case "week", "weekly":
+		return Weekly, nil
+	}
+	return 0, fmt.Errorf("unknown period %q", s)
+}
+
+// begin returns the start of the day or week, which starts on Monday,
+// containing t.
+func (p Period) begin(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if p == Weekly {
+		day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day
+}
+
+// next returns the start of the day or week after the one starting at t.
+func (p Period) next(t time.Time) time.Time {
+	if p == Weekly {
+		return t.AddDate(0, 0, 7)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// label names the day or week starting at t.
+func (p Period) label(t time.Time) string {
+	if p == Weekly {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return t.Format("2006-01-02 Mon")
+}
+
+// Row is the time spent on one name in one day or week.
+type Row struct {
+	Start time.Time // of the day or week
+	Name  string
+	Total time.Duration
+}
+
+// Summarize totals intervals per name per day or week in loc, splitting
+// intervals that span more than one. Rows are ordered by period, then by
+// name.
+func Summarize(intervals []Interval, by Period, loc *time.Location) []Row {
+	type key struct {
+		start time.Time
+		name  string
+	}
+	totals := make(map[key]time.Duration)
+	for _, iv := range intervals {
+		start, end := iv.Start.In(loc), iv.End.In(loc)
+		for start.Before(end) {
+			period := by.begin(start)
+			stop := by.next(period)
+			if end.Before(stop) {
+				stop = end
+			}
+			totals[key{period, iv.Name}] += stop.Sub(start)
+			start = stop
+		}
+	}
+	rows := make([]Row, 0, len(totals))
+	for k, total := range totals {
+		rows = append(rows, Row{Start: k.start, Name: k.name, Total: total})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Start.Equal(rows[j].Start) {
+			return rows[i].Start.Before(rows[j].Start)
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// formatTotal writes a duration as H:MM.
+func formatTotal(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// WriteReport writes rows as a table, with a total under each day or week.
+func WriteReport(w io.Writer, rows []Row, by Period) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	heading := "DAY"
+	if by == Weekly {
+		heading = "WEEK"
+	}
+	fmt.Fprintf(tw, "%s\tNAME\tTIME\n", heading)
+	for i := 0; i < len(rows); {
+		start := rows[i].Start
+		var total time.Duration
+		for ; i < len(rows) && rows[i].Start.Equal(start); i++ {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", by.label(start), rows[i].Name, formatTotal(rows[i].Total))
+			total += rows[i].Total
+		}
+		fmt.Fprintf(tw, "\tTotal\t%s\n", formatTotal(total))
+	}
+	return tw.Flush()
+}
+
+// WriteReportCSV writes rows as CSV, with the time in hours.
+func WriteReportCSV(w io.Writer, rows []Row, by Period) error {
+	cw := csv.NewWriter(w)
+	heading := "day"
+	if by == Weekly {
+		heading = "week"
+	}
+	cw.Write([]string{heading, "name", "hours"})
+	for _, r := range rows {
+		period := r.Start.Format("2006-01-02")
+		if by == Weekly {
+			period = by.label(r.Start)
+		}
+		cw.Write([]string{period, r.Name, fmt.Sprintf("%.2f", r.Total.Hours())})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,223 @@
+// Package timer provides a countdown timer, Pomodoro cycles and a daily
+// tally of finished Pomodoros.
+package timer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/atomicfile"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+)
+
+// Timer counts down from a set length, with pause and resume.
+type Timer struct {
+	clock   chrono.Clock
+	length  time.Duration
+	left    time.Duration // remaining while paused
+	endsAt  time.Time     // when it runs out, while running
+	running bool
+}
+
+// New creates a Timer set to d.
+func New(d time.Duration) *Timer {
+	return NewWithClock(d, chrono.System)
+}
+
+// NewWithClock creates a Timer set to d that reads the time from c.
+func NewWithClock(d time.Duration, c chrono.Clock) *Timer {
+	return &Timer{clock: c, length: d, left: d}
+}
+
+// Set stops the timer and sets it to d.
+func (t *Timer) Set(d time.Duration) {
+	t.length, t.left = d, d
+	t.running = false
+}
+
+// Toggle starts, pauses or resumes the timer. Starting a timer that has
+// run out starts it again from its length.
+func (t *Timer) Toggle() {
+	if t.running {
+		t.left = t.Remaining()
+		t.running = false
+		return
+	}
+	if t.left <= 0 {
+		t.left = t.length
+	}
+	if t.left > 0 {
+		t.endsAt = t.clock.Now().Add(t.left)
+		t.running = true
+	}
+}
+
+// Add adds d to the time remaining, and to the length so that progress
+// stays in proportion.
+func (t *Timer) Add(d time.Duration) {
+	t.length += d
+	if t.running {
+		t.endsAt = t.endsAt.Add(d)
+	} else {
+		t.left += d
+	}
+}
+
+// Reset stops the timer and sets it back to its length.
+func (t *Timer) Reset() {
+	t.left = t.length
+	t.running = false
+}
+
+// Remaining returns the time left.
+func (t *Timer) Remaining() time.Duration {
+	if t.running {
+		return max(0, t.endsAt.Sub(t.clock.Now()))
+	}
+	return t.left
+}
+
+// Length returns what the timer was set to, plus any time added.
+func (t *Timer) Length() time.Duration {
+	return t.length
+}
+
+// IsRunning returns whether the timer is counting down.
+func (t *Timer) IsRunning() bool {
+	return t.running
+}
+
+// Expired reports whether the timer has just run out, stopping it. It
+// returns true only once for each run.
+func (t *Timer) Expired() bool {
+	if !t.running || t.Remaining() > 0 {
+		return false
+	}
+	t.left = 0
+	t.running = false
+	return true
+}
+
+// Phase is a part of a Pomodoro cycle.
+type Phase int
+
+const (
+	Work Phase = iota
+	ShortBreak
+	LongBreak
+)
+
+func (p Phase) String() string {
+	switch p {
+	case ShortBreak:
+		return "Short break"
+	case LongBreak:
+		return "Long break"
+	}
+	return "Work"
+}
+
+// Pomodoro alternates work with breaks, every LongEvery-th break being a
+// long one.
+type Pomodoro struct {
+	Work       time.Duration
+	ShortBreak time.Duration
+	LongBreak  time.Duration
+	LongEvery  int
+
+	phase  Phase
+	worked int // work sessions finished since the last long break
+}
+
+// Phase returns the current phase.
+func (p *Pomodoro) Phase() Phase {
+	return p.phase
+}
+
+// Length returns how long the current phase lasts.
+func (p *Pomodoro) Length() time.Duration {
+	switch p.phase {
+	case ShortBreak:
+		return p.ShortBreak
+	case LongBreak:
+		return p.LongBreak
+	}
+	return p.Work
+}
+
+// Round returns which work session of the cycle the current phase belongs
+// to, counting from 1.
+func (p *Pomodoro) Round() int {
+	if p.phase == Work {
+		return p.worked + 1
+	}
+	return max(p.worked, 1)
+}
+
+// Advance moves to the phase after the current one and returns it.
+func (p *Pomodoro) Advance() Phase {
+	switch p.phase {
+	case Work:
+		p.worked++
+		p.phase = ShortBreak
+		if p.LongEvery > 0 && p.worked >= p.LongEvery {
+			p.phase = LongBreak
+		}
+	case LongBreak:
+		p.worked = 0
+		p.phase = Work
+	default:
+		p.phase = Work
+	}
+	return p.phase
+}
+
+// Tally counts the Pomodoros finished today, kept in a file so that it
+// survives restarts.
+type Tally struct {
+	Date  string `json:"date"` // YYYY-MM-DD the count is for
+	Count int    `json:"count"`
+	path  string
+}
+
+// LoadTally reads the tally stored at path. A missing file counts zero.
+func LoadTally(path string) (*Tally, error) {
+	t := &Tally{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read tally: %w", err)
+	}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("parse tally %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Today returns the number of Pomodoros finished on the day of now.
+func (t *Tally) Today(now time.Time) int {
+	if t.Date != now.Format("2006-01-02") {
+		return 0
+	}
+	return t.Count
+}
+
+// Add counts a Pomodoro finished at now and saves the tally.
+func (t *Tally) Add(now time.Time) error {
+	t.Count = t.Today(now) + 1
+	t.Date = now.Format("2006-01-02")
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("encode tally: %w", err)
+	}
+	if err := atomicfile.Write(t.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("save tally: %w", err)
+	}
+	return nil
+}
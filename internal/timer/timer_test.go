@@ -0,0 +1,119 @@
+package timer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/chrono"
+)
+
+func TestTimer(t *testing.T) {
+	clk := chrono.NewFake(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+	tm := NewWithClock(time.Minute, clk)
+	if tm.IsRunning() || tm.Remaining() != time.Minute {
+		t.Fatalf("expected a stopped one-minute timer, got %v", tm.Remaining())
+	}
+	tm.Toggle()
+	clk.Advance(20 * time.Second)
+	if !tm.IsRunning() || tm.Remaining() != 40*time.Second {
+		t.Errorf("expected 40s left, got %v", tm.Remaining())
+	}
+	tm.Toggle()
+	clk.Advance(time.Hour)
+	if tm.Remaining() != 40*time.Second {
+		t.Errorf("expected a paused timer to hold its time, got %v", tm.Remaining())
+	}
+
+	tm.Add(time.Minute)
+	if tm.Remaining() != 100*time.Second || tm.Length() != 2*time.Minute {
+		t.Errorf("Add: remaining %v, length %v", tm.Remaining(), tm.Length())
+	}
+	tm.Toggle()
+	tm.Add(time.Minute)
+	if tm.Remaining() != 160*time.Second {
+		t.Errorf("Add while running: remaining %v", tm.Remaining())
+	}
+	tm.Reset()
+	if tm.Remaining() != 3*time.Minute || tm.IsRunning() {
+		t.Errorf("expected Reset to go back to the length, got %v", tm.Remaining())
+	}
+	if tm.Expired() {
+		t.Error("expected a stopped timer not to expire")
+	}
+}
+
+func TestTimer_Expired(t *testing.T) {
+	clk := chrono.NewFake(time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC))
+	tm := NewWithClock(25*time.Minute, clk)
+	tm.Toggle()
+	clk.Advance(25*time.Minute - time.Millisecond)
+	if tm.Expired() {
+		t.Error("expired too early")
+	}
+	clk.Advance(time.Millisecond)
+	if !tm.Expired() {
+		t.Fatal("expected the timer to expire on time")
+	}
+	if tm.Expired() || tm.IsRunning() || tm.Remaining() != 0 {
+		t.Error("expected the timer to expire once and stop")
+	}
+
+	// Starting it again restarts from its length.
+	tm.Toggle()
+	if r := tm.Remaining(); r != 25*time.Minute {
+		t.Errorf("expected a restart, got %v", r)
+	}
+}
+
+func TestPomodoro(t *testing.T) {
+	p := &Pomodoro{Work: 25 * time.Minute, ShortBreak: 5 * time.Minute, LongBreak: 15 * time.Minute, LongEvery: 2}
+	want := []struct {
+		phase  Phase
+		length time.Duration
+		round  int
+	}{
+		{Work, 25 * time.Minute, 1},
+		{ShortBreak, 5 * time.Minute, 1},
+		{Work, 25 * time.Minute, 2},
+		{LongBreak, 15 * time.Minute, 2},
+		{Work, 25 * time.Minute, 1},
+	}
+	for i, w := range want {
+		if i > 0 {
+			p.Advance()
+		}
+		if p.Phase() != w.phase || p.Length() != w.length || p.Round() != w.round {
+			t.Errorf("step %d: %v %v round %d, want %v %v round %d", i, p.Phase(), p.Length(), p.Round(), w.phase, w.length, w.round)
+		}
+	}
+}
+
+func TestTally(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pomodoro.json")
+	tally, err := LoadTally(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 6, 3, 10, 0, 0, 0, time.Local)
+	tally.Add(day)
+	if err := tally.Add(day.Add(time.Hour)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	loaded, err := LoadTally(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Today(day); got != 2 {
+		t.Errorf("Today = %d, want 2", got)
+	}
+	tomorrow := day.AddDate(0, 0, 1)
+	if got := loaded.Today(tomorrow); got != 0 {
+		t.Errorf("expected the tally to start again the next day, got %d", got)
+	}
+	loaded.Add(tomorrow)
+	if got := loaded.Today(tomorrow); got != 1 {
+		t.Errorf("Today = %d, want 1", got)
+	}
+}
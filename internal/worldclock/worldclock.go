@@ -0,0 +1,211 @@
+// Package worldclock keeps the time zones of the World Clock mode and the
+// working hours kept in them, saved to a JSON file.
+package worldclock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/alarm"
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/atomicfile"
+)
+
+// Zone is one time zone on the world clock.
+type Zone struct {
+	// Label names the zone, usually after a city, e.g. "Nairobi".
+	Label string `json:"label"`
+	// Name is the IANA name of the zone, e.g. "Africa/Nairobi".
+	Name string `json:"zone"`
+
+	loc *time.Location
+}
+
+// Location returns the zone's location.
+func (z *Zone) Location() *time.Location {
+	return z.loc
+}
+
+// CityLabel makes a label from an IANA zone name: "America/New_York"
+// becomes "New York".
+func CityLabel(name string) string {
+	return strings.ReplaceAll(name[strings.LastIndex(name, "/")+1:], "_", " ")
+}
+
+// Time is the time in a zone at one instant.
+type Time struct {
+	time.Time
+	// DayOffset is how many days the zone's date is ahead of the local one,
+	// or behind when negative.
+	DayOffset int
+	// Offset is the zone's offset from UTC.
+	Offset time.Duration
+}
+
+// At returns the time in the zone at now, comparing its date with the date
+// at now in now's own location.
+func (z *Zone) At(now time.Time) Time {
+	t := now.In(z.loc)
+	_, offset := t.Zone()
+	return Time{Time: t, DayOffset: dayOffset(now, t), Offset: time.Duration(offset) * time.Second}
+}
+
+// dayOffset returns how many calendar days the date of t is after the date
+// of local.
+func dayOffset(local, t time.Time) int {
+	date := func(t time.Time) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return int(date(t).Sub(date(local)) / (24 * time.Hour))
+}
+
+// OffsetText writes a UTC offset such as "UTC+3", "UTC−4" or "UTC+5:30".
+func OffsetText(offset time.Duration) string {
+	if offset == 0 {
+		return "UTC"
+	}
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "−", -offset
+	}
+	h, m := int(offset/time.Hour), int(offset%time.Hour/time.Minute)
+	if m != 0 {
+		return fmt.Sprintf("UTC%s%d:%02d", sign, h, m)
+	}
+	return fmt.Sprintf("UTC%s%d", sign, h)
+}
+
+// DayOffsetText writes a day offset as "+1" or "−1", or "" for the same day.
+func DayOffsetText(days int) string {
+	switch {
+	case days > 0:
+		return fmt.Sprintf("+%d", days)
+	case days < 0:
+		return fmt.Sprintf("−%d", -days)
+	}
+	return ""
+}
+
+// Hours is the working day: the time of day it starts and ends, and the
+// days it is kept.
+type Hours struct {
+	// From and To are the times of day, "HH:MM"; To may be earlier than From
+	// for a night shift.
+	From string         `json:"from"`
+	To   string         `json:"to"`
+	Days alarm.Weekdays `json:"days"`
+}
+
+// DefaultHours is 09:00 to 17:00 on weekdays.
+var DefaultHours = Hours{From: "09:00", To: "17:00", Days: alarm.Workdays}
+
+// Contains reports whether t, in its own location, falls within the working
+// hours. A night shift counts from the day it starts.
+func (h Hours) Contains(t time.Time) bool {
+	fh, fm, err := alarm.ParseTime(h.From)
+	if err != nil {
+		return false
+	}
+	th, tm, err := alarm.ParseTime(h.To)
+	if err != nil {
+		return false
+	}
+	from, to, now := fh*60+fm, th*60+tm, t.Hour()*60+t.Minute()
+	if from <= to {
+		return now >= from && now < to && h.Days.Has(t.Weekday())
+	}
+	if now >= from {
+		return h.Days.Has(t.Weekday())
+	}
+	return now < to && h.Days.Has(t.AddDate(0, 0, -1).Weekday())
+}
+
+// List is the zones on the world clock, stored in a file.
+type List struct {
+	Hours Hours   `json:"working_hours"`
+	Zones []*Zone `json:"zones"`
+	path  string
+}
+
+// Load reads the zones stored at path. A missing file yields no zones and
+// the default working hours.
+func Load(path string) (*List, error) {
+	l := &List{Hours: DefaultHours, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read world clock: %w", err)
+	}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("parse world clock %s: %w", path, err)
+	}
+	if _, _, err := alarm.ParseTime(l.Hours.From); err != nil {
+		return nil, fmt.Errorf("world clock %s: working hours: %w", path, err)
+	}
+	if _, _, err := alarm.ParseTime(l.Hours.To); err != nil {
+		return nil, fmt.Errorf("world clock %s: working hours: %w", path, err)
+	}
+	// Working hours on no day at all mean the days were left out.
+	if l.Hours.Days == 0 {
+		l.Hours.Days = DefaultHours.Days
+	}
+	for _, z := range l.Zones {
+		if z.loc, err = time.LoadLocation(z.Name); err != nil {
+			return nil, fmt.Errorf("world clock %s: %w", path, err)
+		}
+		if z.Label == "" {
+			z.Label = CityLabel(z.Name)
+		}
+	}
+	return l, nil
+}
+
+// Save writes the zones back to their file.
+func (l *List) Save() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode world clock: %w", err)
+	}
+	if err := atomicfile.Write(l.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("save world clock: %w", err)
+	}
+	return nil
+}
+
+// Add adds the zone with the given IANA name. An empty label is made from
+// the name.
+func (l *List) Add(name, label string) (*Zone, error) {
+	name, label = strings.TrimSpace(name), strings.TrimSpace(label)
+	if name == "" {
+		return nil, errors.New("enter a time zone, e.g. Europe/London")
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	if label == "" {
+		label = CityLabel(name)
+	}
+	for _, z := range l.Zones {
+		if z.Label == label {
+			return nil, fmt.Errorf("%q is already on the world clock", label)
+		}
+	}
+	z := &Zone{Label: label, Name: name, loc: loc}
+	l.Zones = append(l.Zones, z)
+	return z, nil
+}
+
+// Remove removes the i'th zone.
+func (l *List) Remove(i int) {
+	if i >= 0 && i < len(l.Zones) {
+		l.Zones = append(l.Zones[:i], l.Zones[i+1:]...)
+	}
+}
@@ -0,0 +1,146 @@
+package worldclock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dadyutenga/upgraded-octo-parakeet/internal/alarm"
+)
+
+func TestZone_At(t *testing.T) {
+	l := &List{}
+	tokyo, err := l.Add("Asia/Tokyo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ny, _ := l.Add("America/New_York", "NYC")
+	india, _ := l.Add("Asia/Kolkata", "")
+	if tokyo.Label != "Tokyo" || ny.Label != "NYC" {
+		t.Errorf("labels = %q, %q", tokyo.Label, ny.Label)
+	}
+
+	// 20:00 in Dar es Salaam (UTC+3) is already tomorrow in Tokyo.
+	local := time.FixedZone("EAT", 3*60*60)
+	now := time.Date(2026, 10, 18, 20, 0, 0, 0, local)
+	tests := []struct {
+		zone   *Zone
+		clock  string
+		day    string
+		offset string
+	}{
+		{tokyo, "02:00", "+1", "UTC+9"},
+		{ny, "13:00", "", "UTC−4"},
+		{india, "22:30", "", "UTC+5:30"},
+	}
+	for _, tt := range tests {
+		at := tt.zone.At(now)
+		if got := at.Format("15:04"); got != tt.clock {
+			t.Errorf("%s: time %s, want %s", tt.zone.Label, got, tt.clock)
+		}
+		if got := DayOffsetText(at.DayOffset); got != tt.day {
+			t.Errorf("%s: day offset %q, want %q", tt.zone.Label, got, tt.day)
+		}
+		if got := OffsetText(at.Offset); got != tt.offset {
+			t.Errorf("%s: UTC offset %q, want %q", tt.zone.Label, got, tt.offset)
+		}
+	}
+	// Early in the morning here, it is still yesterday in New York.
+	if d := ny.At(time.Date(2026, 10, 18, 2, 0, 0, 0, local)).DayOffset; d != -1 {
+		t.Errorf("expected New York a day behind, got %d", d)
+	}
+	if OffsetText(0) != "UTC" {
+		t.Error("expected UTC itself to have no offset")
+	}
+}
+
+func TestHours_Contains(t *testing.T) {
+	// 2026-10-16 is a Friday.
+	at := func(day int, clock string) time.Time {
+		tm, _ := time.Parse("15:04", clock)
+		return time.Date(2026, 10, day, tm.Hour(), tm.Minute(), 0, 0, time.UTC)
+	}
+	tests := []struct {
+		h    Hours
+		t    time.Time
+		want bool
+	}{
+		{DefaultHours, at(16, "09:00"), true},
+		{DefaultHours, at(16, "16:59"), true},
+		{DefaultHours, at(16, "17:00"), false},
+		{DefaultHours, at(16, "08:59"), false},
+		{DefaultHours, at(17, "12:00"), false}, // Saturday
+		{Hours{From: "22:00", To: "06:00", Days: alarm.Workdays}, at(16, "23:00"), true},
+		{Hours{From: "22:00", To: "06:00", Days: alarm.Workdays}, at(17, "05:00"), true},  // Friday's shift
+		{Hours{From: "22:00", To: "06:00", Days: alarm.Workdays}, at(19, "05:00"), false}, // Sunday's
+	}
+	for _, tt := range tests {
+		if got := tt.h.Contains(tt.t); got != tt.want {
+			t.Errorf("%+v contains %s = %v, want %v", tt.h, tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestList_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "my-clock", "worldclock.json")
+	l, err := Load(path)
+	if err != nil || len(l.Zones) != 0 || l.Hours != DefaultHours {
+		t.Fatalf("Load of a missing file = %+v, %v", l, err)
+	}
+	if _, err := l.Add("Mars/Olympus_Mons", ""); err == nil {
+		t.Error("expected an unknown zone to be refused")
+	}
+	l.Add("Europe/London", "")
+	l.Add("America/Los_Angeles", "")
+	if _, err := l.Add("Europe/London", ""); err == nil {
+		t.Error("expected a duplicate label to be refused")
+	}
+	l.Remove(0)
+	if err := l.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded.Zones) != 1 || loaded.Zones[0].Label != "Los Angeles" || loaded.Zones[0].Location() == nil {
+		t.Errorf("unexpected zones after reload: %+v", loaded.Zones)
+	}
+}
+
+func TestLoad_HoursWithoutDays(t *testing.T) {
+	for _, data := range []string{
+		`{"working_hours": {"from": "08:00", "to": "16:00"}}`,
+		`{"working_hours": {"from": "08:00", "to": "16:00", "days": ""}}`,
+	} {
+		path := filepath.Join(t.TempDir(), "worldclock.json")
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		l, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if want := (Hours{From: "08:00", To: "16:00", Days: DefaultHours.Days}); l.Hours != want {
+			t.Errorf("expected %+v for %s, got %+v", want, data, l.Hours)
+		}
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`{"zones": [{"zone": "Nowhere/Special"}]}`,
+		`{"working_hours": {"from": "9am", "to": "17:00"}}`,
+		`not json`,
+	} {
+		path := filepath.Join(t.TempDir(), "worldclock.json")
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("expected error for %s", data)
+		}
+	}
+}